@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -15,41 +15,37 @@ var addCmd = &cobra.Command{
 	Example: `
   gopkg add github.com/mattn/go-sqlite3@v1.14.17
   gopkg add -g github.com/user/module@latest
+  gopkg add github.com/spf13/cobra@^v1.8.0
 `,
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		arg := args[0]
 		parts := strings.Split(arg, "@")
 		if len(parts) != 2 {
-			fmt.Println("\033[31m✖️ Invalid format. Use: gopkg add <module>@<version>\033[0m")
-			fmt.Println("\033[34mℹ️  Example: gopkg add github.com/mattn/go-sqlite3@v1.14.17\033[0m")
-			return
+			return usageErrorf("invalid format %q, use: gopkg add <module>@<version> (e.g. github.com/mattn/go-sqlite3@v1.14.17)", arg)
 		}
 
 		module := parts[0]
 		version := parts[1]
+		if err := core.ValidateConstraint(version); err != nil {
+			return usageErrorf("%v", err)
+		}
 
-		tomlPath := core.GetTomlPath(globalFlag)
-		cfg, err := core.LoadToml(tomlPath)
+		project, err := openProject(globalFlag)
 		if err != nil {
-			fmt.Printf("\033[33m⚠️  %s not found. Creating...\033[0m\n", tomlPath)
-			cfg = &core.GopkgToml{
-				Name:         "unnamed",
-				Dependencies: map[string]string{},
-			}
+			return err
 		}
-
-		if cfg.Dependencies == nil {
-			cfg.Dependencies = make(map[string]string)
+		tomlPath := project.Layout.TomlPath()
+		if _, err := os.Stat(tomlPath); os.IsNotExist(err) {
+			warnf("%s not found. Creating...", tomlPath)
 		}
-		cfg.Dependencies[module] = version
 
-		if err := core.SaveToml(tomlPath, cfg); err != nil {
-			fmt.Printf("\033[31m✖️ Failed to save gopkg.toml: %v\033[0m\n", err)
-			return
+		if err := project.Add(module, version); err != nil {
+			return err
 		}
 
-		fmt.Printf("\033[32m✔️ Added %s@%s to %s\033[0m\n", module, version, tomlPath)
+		successf("Added %s@%s to %s", module, version, tomlPath)
+		return nil
 	},
 }
 
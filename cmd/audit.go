@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var (
+	auditDBFlag       string
+	auditUpdateDBFlag bool
+	auditFixFlag      bool
+	auditReachFlag    bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check locked modules against the Go vulnerability database",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		if auditReachFlag && globalFlag {
+			return usageErrorf("--reachability needs a project, it cannot be combined with --global")
+		}
+
+		source := auditDBFlag
+		if auditUpdateDBFlag {
+			from := source
+			if from == "" {
+				from = project.Config.VulnDB()
+			}
+			dir := project.Layout.VulnDBDir()
+			logf("⬇️  Downloading vulnerability database from %s\n", core.RedactURL(from))
+			n, err := core.OpenVulnDB(from).Download(ctx, dir, project.Config.Jobs())
+			if err != nil {
+				return err
+			}
+			successf("Saved %d entries to %s", n, dir)
+			source = dir
+		}
+		if source == "" {
+			source = project.DefaultVulnDB()
+		}
+
+		db := core.OpenVulnDB(source)
+		vulns, err := project.Audit(ctx, db)
+		if err != nil {
+			return err
+		}
+		if auditReachFlag && len(vulns) > 0 {
+			logf("🔎 Analyzing call graph...\n")
+			if err := project.Reachability(ctx, vulns); err != nil {
+				return err
+			}
+		}
+		report := AuditReport{Database: core.RedactURL(db.Source), Vulnerabilities: vulns, Remaining: countFindings(vulns, vulns)}
+
+		var fixErr error
+		if auditFixFlag && len(vulns) > 0 {
+			report.Fixes, err = project.PlanFixes(ctx, vulns)
+			if err != nil {
+				return err
+			}
+			results, err := project.ApplyFixes(ctx, report.Fixes)
+			for _, r := range results {
+				report.Modules = append(report.Modules, moduleReport(r))
+			}
+			fixErr = err
+			if err == nil && len(results) > 0 {
+				remaining, err := project.Audit(ctx, db)
+				if err != nil {
+					return err
+				}
+				report.Remaining = countFindings(remaining, vulns)
+			}
+		}
+
+		render(report, func() { renderAudit(report) })
+		if fixErr != nil {
+			return fixErr
+		}
+		if report.Remaining > 0 {
+			return fmt.Errorf("known vulnerabilities in locked modules: %d: %w", report.Remaining, errVulnerable)
+		}
+		return nil
+	},
+}
+
+// countFindings counts the vulnerabilities in found that should fail the
+// audit: all of them, or with --reachability only those analyzed reachable
+// or unknown in the initial report. A finding the analysis never saw, such
+// as one a fix upgraded into, is unknown too.
+func countFindings(found, analyzed []core.Vulnerability) int {
+	if !auditReachFlag {
+		return len(found)
+	}
+	reach := map[string]core.Reach{}
+	for _, v := range analyzed {
+		reach[v.Module+" "+v.ID] = v.Reachable
+	}
+	n := 0
+	for _, v := range found {
+		if r, ok := reach[v.Module+" "+v.ID]; !ok || r == core.ReachCalled || r == core.ReachUnknown {
+			n++
+		}
+	}
+	return n
+}
+
+var reachLabels = map[core.Reach]statusStyle{
+	core.ReachCalled:   {ansiRed, "⚠️  Reachable"},
+	core.ReachImported: {ansiYellow, "Imported"},
+	core.ReachNone:     {ansiGray, "Unreachable"},
+	core.ReachUnknown:  {ansiYellow, "Unknown"},
+}
+
+var severityColors = map[string]string{
+	"critical": ansiRed,
+	"high":     ansiRed,
+	"moderate": ansiYellow,
+	"medium":   ansiYellow,
+	"low":      ansiGray,
+}
+
+func renderAudit(report AuditReport) {
+	if len(report.Vulnerabilities) == 0 {
+		successf("No known vulnerabilities (database: %s)", report.Database)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	header := []string{"ID", "Module", "Version", "Severity", "Affected", "Fixed in", "Summary"}
+	if auditReachFlag {
+		header = append(header, "Reachability")
+	}
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for _, v := range report.Vulnerabilities {
+		id := v.ID
+		if len(v.Aliases) > 0 {
+			id += "\n" + strings.Join(v.Aliases, "\n")
+		}
+		module := v.Module
+		if v.Indirect {
+			module += colorize(ansiGray, " (indirect)")
+		}
+		severity := v.Severity
+		if v.Score > 0 {
+			severity += fmt.Sprintf(" (%.1f)", v.Score)
+		}
+		severity = colorize(severityColors[v.Severity], severity)
+		row := []string{id, module, v.Version, severity, v.Affected, orDash(v.Fixed), v.Summary}
+		if auditReachFlag {
+			label := reachLabels[v.Reachable]
+			row = append(row, colorize(label.color, label.text))
+		}
+		table.Append(row)
+	}
+	fmt.Println("\n" + colorize(ansiRed, fmt.Sprintf("🛡️  %d vulnerabilities found:", len(report.Vulnerabilities))))
+	table.Render()
+
+	for _, v := range report.Vulnerabilities {
+		if len(v.Trace) > 0 {
+			fmt.Printf("\n%s %s reached via:\n", colorize(ansiRed, v.ID), v.Module)
+			for i, fn := range v.Trace {
+				fmt.Printf("  %s%s\n", strings.Repeat("  ", i), fn)
+			}
+		}
+	}
+
+	for _, f := range report.Fixes {
+		if f.Skipped != "" {
+			warnf("Not fixing %s: %s", f.Module, f.Skipped)
+		}
+	}
+	if len(report.Modules) > 0 {
+		renderUpdateTable(ModulesReport{Modules: report.Modules})
+	}
+	switch {
+	case report.Remaining == 0 && auditReachFlag:
+		successf("None of the vulnerable symbols are reachable from this project")
+	case report.Remaining == 0:
+		successf("All vulnerabilities fixed")
+	case !auditFixFlag:
+		infof("Run `gopkg audit --fix` to upgrade to fixed versions")
+	}
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditDBFlag, "db", "", "Vulnerability database URL or directory (default: vulndb setting)")
+	auditCmd.Flags().BoolVar(&auditUpdateDBFlag, "update-db", false, "Download the database to the gopkg home for offline use")
+	auditCmd.Flags().BoolVar(&auditFixFlag, "fix", false, "Upgrade vulnerable direct dependencies to fixed versions within policy")
+	auditCmd.Flags().BoolVar(&auditReachFlag, "reachability", false, "Check whether the project's call graph reaches the vulnerable symbols")
+	auditCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Audit the global gopkg.lock")
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pageton/gopkg/core"
+)
+
+func TestCountFindings(t *testing.T) {
+	vuln := func(module, id string, reach core.Reach) core.Vulnerability {
+		return core.Vulnerability{ID: id, Module: module, Reachable: reach}
+	}
+	analyzed := []core.Vulnerability{
+		vuln("example.com/a", "GO-2099-0001", core.ReachCalled),
+		vuln("example.com/a", "GO-2099-0002", core.ReachImported),
+		vuln("example.com/b", "GO-2099-0001", core.ReachNone),
+		vuln("example.com/c", "GO-2099-0003", core.ReachUnknown),
+	}
+	// After --fix, the audit runs again without reachability; the findings
+	// keep the reach of the initial report, and d is new.
+	remaining := []core.Vulnerability{
+		vuln("example.com/a", "GO-2099-0002", ""),
+		vuln("example.com/b", "GO-2099-0001", ""),
+		vuln("example.com/c", "GO-2099-0003", ""),
+		vuln("example.com/d", "GO-2099-0004", ""),
+	}
+
+	tests := []struct {
+		name        string
+		reach       bool
+		found, from []core.Vulnerability
+		want        int
+	}{
+		{"without reachability every finding counts", false, analyzed, analyzed, 4},
+		{"reachable and unknown count", true, analyzed, analyzed, 2},
+		{"remaining after a fix", true, remaining, analyzed, 2},
+		{"remaining without reachability", false, remaining, analyzed, 4},
+		{"nothing found", true, nil, analyzed, 0},
+	}
+	defer func(old bool) { auditReachFlag = old }(auditReachFlag)
+	for _, tt := range tests {
+		auditReachFlag = tt.reach
+		if got := countFindings(tt.found, tt.from); got != tt.want {
+			t.Errorf("%s: countFindings = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -13,59 +14,79 @@ import (
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check if any dependencies have newer versions available",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		tomlPath := core.GetTomlPath(globalFlag)
 		cfg, err := core.LoadToml(tomlPath)
 		if err != nil {
-			fmt.Printf("\033[31m✖️ Failed to load %s: %v\033[0m\n", tomlPath, err)
-			return
+			return fmt.Errorf("failed to load %s: %w", tomlPath, err)
 		}
 
-		if len(cfg.Dependencies) == 0 {
-			fmt.Printf("\033[34mℹ️  No dependencies found in %s\033[0m\n", tomlPath)
-			return
+		if len(cfg.Dependencies) == 0 && !structuredOutput() {
+			infof("No dependencies found in %s", tomlPath)
+			return nil
 		}
 
-		lockMap := map[string]core.LockEntry{}
-		locks, _ := core.LoadLockFile(globalFlag)
-		for _, entry := range locks {
-			lockMap[entry.Name] = entry
+		report, errs, err := collectCheck(cmd.Context())
+		if err != nil {
+			return err
 		}
+		render(report, func() {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Module", "Current", "Latest", "Status"})
+			table.SetAutoWrapText(false)
+			table.SetBorder(true)
+			table.SetHeaderLine(true)
+			table.SetRowLine(true)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Module", "Current", "Latest", "Status"})
-		table.SetAutoWrapText(false)
-		table.SetBorder(true)
-		table.SetHeaderLine(true)
-		table.SetRowLine(true)
-		table.SetAlignment(tablewriter.ALIGN_LEFT)
-
-		for module := range cfg.Dependencies {
-			locked, found := lockMap[module]
-			if !found {
-				table.Append([]string{module, "—", "—", "\033[33mNot installed\033[0m"})
-				continue
+			for _, row := range report.Modules {
+				table.Append([]string{row.Module, orDash(row.Locked), orDash(row.Latest), statusLabel(row.Status)})
 			}
 
-			latest, err := core.ResolveLatestVersion(module)
-			if err != nil {
-				table.Append([]string{module, locked.Resolved, "—", "\033[31mFailed to fetch\033[0m"})
-				continue
-			}
+			fmt.Println("\n" + colorize(ansiBlue, "📋 Dependency status:"))
+			table.Render()
+		})
 
-			if core.CompareVersions(latest, locked.Resolved) > 0 {
-				table.Append([]string{module, locked.Resolved, latest, "\033[33mUpdate available\033[0m"})
-			} else {
-				table.Append([]string{module, locked.Resolved, latest, "\033[32mUp to date\033[0m"})
+		if err := failures(errs, "failed to check %d of %d modules", len(errs), len(report.Modules)); err != nil {
+			return err
+		}
+		if checkExitCode {
+			for _, row := range report.Modules {
+				if row.Status == StatusUpdateAvailable {
+					return errUpdatesAvailable
+				}
 			}
 		}
-
-		fmt.Println("\n\033[34m📋 Dependency status:\033[0m")
-		table.Render()
+		return nil
 	},
 }
 
+func collectCheck(ctx context.Context) (ModulesReport, []error, error) {
+	project, err := openProject(globalFlag)
+	if err != nil {
+		return ModulesReport{}, nil, err
+	}
+	results, err := project.Check(ctx)
+	if err != nil {
+		return ModulesReport{}, nil, err
+	}
+
+	report := ModulesReport{Modules: []ModuleReport{}}
+	var errs []error
+	for _, r := range results {
+		report.Modules = append(report.Modules, moduleReport(r))
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return report, errs, nil
+}
+
+var checkExitCode bool
+
 func init() {
 	checkCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Check updates in global gopkg.toml")
+	checkCmd.Flags().
+		BoolVar(&checkExitCode, "exit-code", false, "Exit with status 7 when updates are available")
 	rootCmd.AddCommand(checkCmd)
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -17,42 +18,57 @@ var (
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Clean installed modules, lockfiles, and cache",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		tx, err := project.Begin("clean")
+		if err != nil {
+			return err
+		}
+		tx.Touch()
+
+		var errs []error
 		if globalFlag {
 			modulesDir := core.GetGlobalModulesPath()
 			if err := os.RemoveAll(modulesDir); err == nil {
-				fmt.Printf("\033[32m✔️ Removed global modules: %s\033[0m\n", modulesDir)
+				successf("Removed global modules: %s", modulesDir)
 			} else {
-				fmt.Printf("\033[33m⚠️ Failed to remove global modules: %v\033[0m\n", err)
+				errs = append(errs, fmt.Errorf("failed to remove global modules: %w", err))
 			}
 		} else {
 			modulesDir := core.GetVendorPath()
 			if err := os.RemoveAll(modulesDir); err == nil {
-				fmt.Printf("\033[32m✔️ Removed local modules: %s\033[0m\n", modulesDir)
+				successf("Removed local modules: %s", modulesDir)
 			} else {
-				fmt.Printf("\033[33m⚠️ Failed to remove local modules: %v\033[0m\n", err)
+				errs = append(errs, fmt.Errorf("failed to remove local modules: %w", err))
 			}
 		}
 
 		if cleanLock {
 			lockPath := core.GetLockFilePath(globalFlag)
 			if err := os.Remove(lockPath); err == nil {
-				fmt.Printf("\033[32m✔️ Removed lockfile: %s\033[0m\n", lockPath)
+				successf("Removed lockfile: %s", lockPath)
 			} else {
-				fmt.Printf("\033[33m⚠️ No lockfile found at %s\033[0m\n", lockPath)
+				warnf("No lockfile found at %s", lockPath)
 			}
 		} else {
-			fmt.Println("\033[34mℹ️ Skipping gopkg.lock (use --lock to remove it)\033[0m")
+			infof("Skipping gopkg.lock (use --lock to remove it)")
 		}
 
 		if cleanCache {
 			cacheDir := core.GetCacheDir()
 			if err := os.RemoveAll(cacheDir); err == nil {
-				fmt.Printf("\033[32m✔️ Removed cache: %s\033[0m\n", cacheDir)
+				successf("Removed cache: %s", cacheDir)
 			} else {
-				fmt.Printf("\033[33m⚠️ Failed to remove cache: %v\033[0m\n", err)
+				errs = append(errs, fmt.Errorf("failed to remove cache: %w", err))
 			}
 		}
+		if err := tx.Commit(); err != nil {
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
 	},
 }
 
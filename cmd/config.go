@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var configProject bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and edit gopkg configuration",
+	Long: `Settings are layered: built-in defaults, the user config.toml, the
+[settings] table in gopkg.toml, environment variables, then flags.
+
+Keys: ` + strings.Join(core.SettingKeys(), ", ") + `, auth.<host>.token|username|password`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List effective settings and where they come from",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report := ConfigReport{Settings: cliConfig.Values()}
+		render(report, func() {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Key", "Value", "Source"})
+			table.SetAutoWrapText(false)
+			table.SetRowLine(true)
+			for _, v := range report.Settings {
+				table.Append([]string{v.Key, v.Value, configSourceLabel(v)})
+			}
+			table.Render()
+		})
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, err := cliConfig.Get(args[0])
+		if err != nil {
+			return err
+		}
+		render(value, func() {
+			fmt.Println(value.Value)
+			if verboseFlag {
+				fmt.Println(colorize(ansiGray, "# from "+configSourceLabel(value)))
+			}
+		})
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in the user config, or gopkg.toml with --project",
+	Example: `
+  gopkg config set jobs 8
+  gopkg config set --project proxy https://proxy.corp.example
+  gopkg config set auth.proxy.corp.example.token s3cr3t
+`,
+	Args: usageArgs(cobra.ExactArgs(2)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[1] == "" {
+			return usageErrorf("empty value, use `gopkg config unset %s`", args[0])
+		}
+		path, err := writeConfig(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		successf("Set %s in %s", args[0], path)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a value from the user config, or gopkg.toml with --project",
+	Args:  usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := writeConfig(args[0], "")
+		if err != nil {
+			return err
+		}
+		successf("Unset %s in %s", args[0], path)
+		return nil
+	},
+}
+
+func writeConfig(key, value string) (string, error) {
+	layout := core.DefaultLayout(false)
+	if configProject {
+		path := layout.TomlPath()
+		return path, core.SetProjectConfig(path, key, value)
+	}
+	path := layout.ConfigPath()
+	return path, core.SetUserConfig(path, key, value)
+}
+
+func configSourceLabel(v core.ConfigValue) string {
+	if v.Origin == "" {
+		return string(v.Source)
+	}
+	return fmt.Sprintf("%s (%s)", v.Source, v.Origin)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{configSetCmd, configUnsetCmd} {
+		c.Flags().BoolVar(&configProject, "project", false, "Write to the [settings] table in ./gopkg.toml")
+	}
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd, configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
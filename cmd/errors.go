@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+const (
+	ExitOK               = 0
+	ExitError            = 1
+	ExitUsage            = 2
+	ExitNotFound         = 3
+	ExitNetwork          = 4
+	ExitIntegrity        = 5
+	ExitConflict         = 6
+	ExitUpdatesAvailable = 7
+	ExitVulnerable       = 8
+	ExitPolicy           = 9
+	ExitInterrupted      = 130
+)
+
+var (
+	errUpdatesAvailable = errors.New("updates available")
+	errVulnerable       = errors.New("vulnerable dependencies")
+)
+
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// failuresError reports a command that ran to completion but had per-module
+// failures; the individual errors stay reachable for exit code mapping.
+type failuresError struct {
+	msg  string
+	errs []error
+}
+
+func (e *failuresError) Error() string   { return e.msg }
+func (e *failuresError) Unwrap() []error { return e.errs }
+
+func failures(errs []error, format string, args ...any) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &failuresError{msg: fmt.Sprintf(format, args...), errs: errs}
+}
+
+func usageArgs(fn cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := fn(cmd, args); err != nil {
+			return &usageError{err}
+		}
+		return nil
+	}
+}
+
+func exitCode(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, errUpdatesAvailable):
+		return ExitUpdatesAvailable
+	case errors.Is(err, errVulnerable):
+		return ExitVulnerable
+	case errors.As(err, &usage), errors.Is(err, core.ErrInvalid), strings.HasPrefix(err.Error(), "unknown command"):
+		return ExitUsage
+	case errors.Is(err, core.ErrIntegrity):
+		return ExitIntegrity
+	case errors.Is(err, core.ErrPolicy):
+		return ExitPolicy
+	case errors.Is(err, core.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, core.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return ExitNotFound
+	case errors.Is(err, core.ErrNetwork):
+		return ExitNetwork
+	default:
+		return ExitError
+	}
+}
+
+func errorKind(code int) string {
+	switch code {
+	case ExitUsage:
+		return "usage"
+	case ExitNotFound:
+		return "not-found"
+	case ExitNetwork:
+		return "network"
+	case ExitIntegrity:
+		return "integrity"
+	case ExitConflict:
+		return "conflict"
+	case ExitUpdatesAvailable:
+		return "updates-available"
+	case ExitVulnerable:
+		return "vulnerable"
+	case ExitPolicy:
+		return "policy"
+	case ExitInterrupted:
+		return "interrupted"
+	default:
+		return "error"
+	}
+}
+
+func reportError(err error) int {
+	code := exitCode(err)
+	if code == ExitUpdatesAvailable && !structuredOutput() {
+		return code
+	}
+
+	report := ErrorReport{Error: err.Error(), Kind: errorKind(code), Code: code}
+	switch outputFormat {
+	case outputJSON, outputYAML:
+		renderTo(os.Stderr, report, nil)
+	default:
+		fmt.Fprintln(os.Stderr, colorize(ansiRed, "✖️ "+report.Error))
+		if code == ExitUsage {
+			fmt.Fprintln(os.Stderr, colorize(ansiBlue, "ℹ️  Run 'gopkg --help' for usage"))
+		}
+	}
+	return code
+}
+
+func usageErrorf(format string, args ...any) error {
+	return &usageError{fmt.Errorf(format, args...)}
+}
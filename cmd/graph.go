@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/module"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var (
+	graphFormat  string
+	graphOffline bool
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the full dependency graph",
+	Example: `
+  gopkg graph > deps.dot
+  gopkg graph --format mermaid
+  gopkg graph --format json
+`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch graphFormat {
+		case "dot", "mermaid", "json":
+		default:
+			return usageErrorf("invalid --format %q (use dot, mermaid or json)", graphFormat)
+		}
+
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		graph, err := project.LoadGraph(cmd.Context(), core.GraphOptions{Offline: graphOffline})
+		if err != nil {
+			return err
+		}
+		warnMissing(graph)
+
+		report := collectGraph(graph)
+		if graphFormat == "json" && !structuredOutput() {
+			outputFormat = outputJSON
+		}
+		render(report, func() {
+			if graphFormat == "mermaid" {
+				writeMermaid(os.Stdout, report)
+			} else {
+				writeDot(os.Stdout, report)
+			}
+		})
+		return nil
+	},
+}
+
+func collectGraph(graph *core.Graph) GraphReport {
+	report := GraphReport{Name: graph.Name, Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+
+	nodes := map[module.Version]bool{}
+	for _, root := range graph.Roots {
+		nodes[root] = true
+		report.Edges = append(report.Edges, GraphEdge{From: graph.Name, To: moduleRef(graph, root).String()})
+	}
+	froms := make([]module.Version, 0, len(graph.Edges))
+	for from, reqs := range graph.Edges {
+		froms = append(froms, from)
+		for _, r := range reqs {
+			nodes[r] = true
+		}
+	}
+	sort.Slice(froms, func(i, j int) bool { return froms[i].String() < froms[j].String() })
+	for _, from := range froms {
+		for _, r := range graph.Edges[from] {
+			report.Edges = append(report.Edges, GraphEdge{From: moduleRef(graph, from).String(), To: moduleRef(graph, r).String()})
+		}
+	}
+
+	for mv := range nodes {
+		ref := moduleRef(graph, mv)
+		report.Nodes = append(report.Nodes, GraphNode{
+			ID:       ref.String(),
+			Module:   ref.Module,
+			Version:  ref.Version,
+			Path:     ref.Path,
+			Selected: graph.Selected[mv.Path] == mv.Version,
+			Direct:   graph.IsRoot(mv.Path),
+		})
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool { return report.Nodes[i].ID < report.Nodes[j].ID })
+	return report
+}
+
+func writeDot(w io.Writer, report GraphReport) {
+	fmt.Fprintln(w, "digraph gopkg {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintf(w, "  %q [shape=box];\n", report.Name)
+	for _, n := range report.Nodes {
+		attrs := []string{}
+		if n.Direct {
+			attrs = append(attrs, "penwidth=2")
+		}
+		if !n.Selected {
+			attrs = append(attrs, "style=dashed", "color=gray")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(w, "  %q [%s];\n", n.ID, strings.Join(attrs, ","))
+		}
+	}
+	for _, e := range report.Edges {
+		fmt.Fprintf(w, "  %q -> %q;\n", e.From, e.To)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeMermaid(w io.Writer, report GraphReport) {
+	ids := map[string]string{report.Name: "root"}
+	for i, n := range report.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	fmt.Fprintln(w, "graph LR")
+	fmt.Fprintf(w, "  root[%q]\n", report.Name)
+	for _, n := range report.Nodes {
+		fmt.Fprintf(w, "  %s[%q]\n", ids[n.ID], n.ID)
+		if !n.Selected {
+			fmt.Fprintf(w, "  class %s superseded\n", ids[n.ID])
+		}
+	}
+	for _, e := range report.Edges {
+		fmt.Fprintf(w, "  %s --> %s\n", ids[e.From], ids[e.To])
+	}
+	fmt.Fprintln(w, "  classDef superseded stroke-dasharray: 4 4,color:#888")
+}
+
+func init() {
+	graphCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Use the global gopkg.toml")
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Graph format: dot, mermaid or json")
+	graphCmd.Flags().BoolVar(&graphOffline, "offline", false, "Only use installed and cached go.mod files")
+	rootCmd.AddCommand(graphCmd)
+}
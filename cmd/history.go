@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the commands that changed dependencies or gopkg.lock",
+	Example: `
+  gopkg history
+  gopkg history show 3
+`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		entries, err := project.History()
+		if err != nil {
+			return err
+		}
+
+		report := HistoryReport{Entries: append([]core.HistoryEntry{}, entries...)}
+		render(report, func() {
+			if len(report.Entries) == 0 {
+				infof("No history yet.")
+				return
+			}
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"ID", "Time", "User", "Command", "Changes", "Lock"})
+			table.SetAutoWrapText(false)
+			for i := len(report.Entries) - 1; i >= 0; i-- {
+				e := report.Entries[i]
+				table.Append([]string{
+					strconv.Itoa(e.ID),
+					e.Time.Local().Format(time.DateTime),
+					orDash(e.User),
+					e.Command,
+					orDash(changeSummary(e.Changes)),
+					orDash(shortHash(e.LockAfter)),
+				})
+			}
+			table.Render()
+		})
+		return nil
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show what a recorded command changed",
+	Args:  usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return usageErrorf("invalid history id %q", args[0])
+		}
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		entry, err := project.HistoryEntry(id)
+		if err != nil {
+			return err
+		}
+
+		render(entry, func() {
+			fmt.Printf("%s %s by %s at %s\n", colorize(ansiBlue, fmt.Sprintf("#%d", entry.ID)), entry.Command, orDash(entry.User), entry.Time.Local().Format(time.DateTime))
+			if entry.LockBefore != entry.LockAfter {
+				fmt.Printf("gopkg.lock: %s → %s\n", orDash(shortHash(entry.LockBefore)), orDash(shortHash(entry.LockAfter)))
+			}
+			if entry.Snapshot != "" {
+				fmt.Printf("snapshot:   %s\n", entry.Snapshot)
+			}
+			fmt.Println()
+			if len(entry.Changes) == 0 {
+				infof("No dependency versions changed.")
+				return
+			}
+			for _, c := range entry.Changes {
+				fmt.Println(formatChange(c))
+			}
+		})
+		return nil
+	},
+}
+
+var changeColors = map[core.ChangeKind]string{
+	core.ChangeAdded:      ansiGreen,
+	core.ChangeRemoved:    ansiRed,
+	core.ChangeUpgraded:   ansiCyan,
+	core.ChangeDowngraded: ansiYellow,
+	core.ChangeChanged:    ansiYellow,
+}
+
+func formatChange(c core.VersionChange) string {
+	switch c.Kind() {
+	case core.ChangeAdded:
+		return colorize(ansiGreen, "+ "+c.Module+" "+c.After)
+	case core.ChangeRemoved:
+		return colorize(ansiRed, "- "+c.Module+" "+c.Before)
+	}
+	return colorize(changeColors[c.Kind()], fmt.Sprintf("~ %s %s → %s (%s)", c.Module, c.Before, c.After, c.Kind()))
+}
+
+// changeSummary condenses changes into e.g. "1 added, 2 upgraded".
+func changeSummary(changes []core.VersionChange) string {
+	counts := map[core.ChangeKind]int{}
+	for _, c := range changes {
+		counts[c.Kind()]++
+	}
+	var parts []string
+	for _, kind := range []core.ChangeKind{core.ChangeAdded, core.ChangeRemoved, core.ChangeUpgraded, core.ChangeDowngraded, core.ChangeChanged} {
+		if n := counts[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortHash(h string) string {
+	h = strings.TrimPrefix(h, "sha256:")
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func init() {
+	historyCmd.PersistentFlags().BoolVarP(&globalFlag, "global", "g", false, "Show the history of the global gopkg.toml")
+	historyCmd.AddCommand(historyShowCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -14,11 +14,10 @@ import (
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize gopkg in the current directory",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		tomlPath := "gopkg.toml"
 		if _, err := os.Stat(tomlPath); err == nil {
-			fmt.Println("\033[31m✖️ gopkg.toml already exists\033[0m")
-			return
+			return core.NewError(core.ErrConflict, "gopkg.toml already exists")
 		}
 
 		projectName := filepath.Base(core.GetCurrentDir())
@@ -29,24 +28,22 @@ var initCmd = &cobra.Command{
 
 		buf, err := toml.Marshal(data)
 		if err != nil {
-			fmt.Printf("\033[31m✖️ Failed to encode gopkg.toml: %v\033[0m\n", err)
-			return
+			return fmt.Errorf("failed to encode gopkg.toml: %w", err)
 		}
 
 		if err := os.WriteFile(tomlPath, buf, 0644); err != nil {
-			fmt.Printf("\033[31m✖️ Failed to write gopkg.toml: %v\033[0m\n", err)
-			return
+			return fmt.Errorf("failed to write gopkg.toml: %w", err)
 		}
 
 		modulesDir := core.GetVendorPath()
 		if _, err := os.Stat(modulesDir); os.IsNotExist(err) {
 			if err := os.Mkdir(modulesDir, 0755); err != nil {
-				fmt.Printf("\033[31m✖️ Failed to create gopkg_modules directory: %v\033[0m\n", err)
-				return
+				return fmt.Errorf("failed to create gopkg_modules directory: %w", err)
 			}
 		}
 
-		fmt.Println("\033[32m✔️ Initialized new gopkg.toml project.\033[0m")
+		successf("Initialized new gopkg.toml project.")
+		return nil
 	},
 }
 
@@ -3,10 +3,6 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"sort"
-	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -15,185 +11,117 @@ import (
 )
 
 var (
-	globalFlag bool
-	autoFlag   bool
+	globalFlag    bool
+	autoFlag      bool
+	frozenFlag    bool
+	jobsFlag      int
+	workspaceFlag bool
+	workFlag      bool
 )
 
 var installCmd = &cobra.Command{
 	Use:     "install",
 	Short:   "Install all dependencies from gopkg.toml",
 	Aliases: []string{"i"},
-	Run: func(cmd *cobra.Command, args []string) {
-		tomlPath := core.GetTomlPath(globalFlag)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
 
-		var cfg *core.GopkgToml
-		var err error
+		if frozenFlag && autoFlag {
+			return usageErrorf("--frozen cannot be combined with --auto")
+		}
+		if workspaceFlag && (autoFlag || globalFlag) {
+			return usageErrorf("--workspace cannot be combined with --auto or --global")
+		}
 
+		opts := core.InstallOptions{Frozen: frozenFlag}
 		if autoFlag {
-			imports, err := core.ScanImports(".")
+			imports, err := core.ScanImports(project.Layout.Root)
 			if err != nil {
-				fmt.Println("Failed to scan Go files:", err)
-				return
+				return fmt.Errorf("failed to scan Go files: %w", err)
 			}
 
-			cfg, _ = core.LoadToml(tomlPath)
-			if cfg == nil {
-				cfg = &core.GopkgToml{
-					Name:         filepath.Base(filepath.Dir(tomlPath)),
-					Dependencies: map[string]string{},
-				}
+			cfg, _, err := project.LoadOrCreateManifest()
+			if err != nil {
+				return err
 			}
 
+			opts.Add = map[string]string{}
 			for _, imp := range imports {
 				if _, ok := cfg.Dependencies[imp]; !ok {
-					cfg.Dependencies[imp] = "latest"
-					fmt.Println("➕ Auto-added:", imp)
+					opts.Add[imp] = "latest"
+					logf("➕ Auto-added: %s\n", imp)
 				}
 			}
+		}
+
+		logf("\n🔧 Installing dependencies...\n")
 
-			if err := core.SaveToml(tomlPath, cfg); err != nil {
-				fmt.Println("Failed to update gopkg.toml:", err)
-				return
+		var result *core.InstallResult
+		if workspaceFlag {
+			ws, werr := project.LoadWorkspace()
+			if werr != nil {
+				return werr
 			}
+			logf("📚 Workspace with %d members\n", len(ws.Members))
+			result, err = ws.Install(ctx, opts)
 		} else {
-			cfg, err = core.LoadToml(tomlPath)
-			if err != nil {
-				cfg = &core.GopkgToml{
-					Name:         filepath.Base(filepath.Dir(tomlPath)),
-					Dependencies: map[string]string{},
-				}
-				if err := core.SaveToml(tomlPath, cfg); err != nil {
-					fmt.Printf("Failed to create gopkg.toml: %v\n", err)
-					return
-				}
-			}
+			result, err = project.Install(ctx, opts)
 		}
-
-		// 🔐 Load lockfile
-		lockMap := make(map[string]core.LockEntry)
-		if lockEntries, err := core.LoadLockFile(globalFlag); err == nil {
-			for _, entry := range lockEntries {
-				lockMap[entry.Name] = entry
-			}
+		if result == nil {
+			return err
 		}
 
-		if _, err := os.Stat("go.mod"); os.IsNotExist(err) {
-			wd, _ := os.Getwd()
-			projectName := filepath.Base(wd)
-			_ = exec.Command("go", "mod", "init", projectName).Run()
+		report := InstallReport{Modules: []ModuleReport{}, LockUpdated: result.LockUpdated}
+		for _, m := range result.Modules {
+			report.Modules = append(report.Modules, moduleReport(m))
 		}
-
-		fmt.Println("\n🔧 Installing dependencies...")
-
-		modules := make([]string, 0, len(cfg.Dependencies))
-		for m := range cfg.Dependencies {
-			modules = append(modules, m)
+		render(report, func() { renderInstallTable(report) })
+		if err != nil {
+			return err
 		}
-		sort.Strings(modules)
-
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Module", "Version", "Resolved", "Status"})
-		table.SetAutoWrapText(false)
-		table.SetBorder(true)
-		table.SetRowLine(true)
-
-		var newLock []core.LockEntry
-
-		for i, module := range modules {
-			version := cfg.Dependencies[module]
-			fmt.Printf("[%d/%d] Installing %s@%s... \n", i+1, len(modules), module, version)
-
-			var meta *core.ModuleMetadata
-			var resolvedVersion string
-			status := ""
-
-			if lockEntry, ok := lockMap[module]; ok && lockEntry.Version == version {
-				resolvedVersion = lockEntry.Resolved
-				meta = &core.ModuleMetadata{
-					Version: lockEntry.Resolved,
-					Time:    parseTime(lockEntry.ResolvedTime),
-					Hash:    lockEntry.Hash,
-				}
-				status = "Locked"
-			} else {
-				meta, err = core.FetchModuleMetadata(module, version)
-				if err != nil {
-					status = "\033[31mFailed\033[0m"
-					table.Append([]string{module, version, "—", status})
-					continue
-				}
-				resolvedVersion = meta.Version
-				status = "Installed"
-			}
-
-			localPath := core.GetVendorPath()
-			if globalFlag {
-				localPath = core.GetGlobalModulePath(module)
-			} else {
-				localPath = filepath.Join(core.GetVendorPath(), module)
-			}
 
-			modFile := filepath.Join(localPath, "go.mod")
-			if _, err := os.Stat(modFile); err != nil {
-				zipPath, err := core.DownloadModuleZip(module, resolvedVersion)
-				if err != nil {
-					status = "Download"
-					table.Append([]string{module, version, resolvedVersion, status})
-					continue
-				}
-				err = core.ExtractZip(zipPath, localPath, resolvedVersion, true, globalFlag)
-				if err != nil {
-					status = "Extract"
-					table.Append([]string{module, version, resolvedVersion, status})
-					continue
-				}
-			}
-
-			relPath := localPath
-			if !globalFlag {
-				relPath, _ = filepath.Rel(".", localPath)
-				relPath = "./" + relPath
-			}
-			err = core.AddReplaceToGoMod(module, relPath, resolvedVersion)
-			if err != nil {
-				status = "✖️ Replace"
-				table.Append([]string{module, version, resolvedVersion, status})
-				continue
-			}
+		errs := result.Errors()
+		return failures(errs, "%d of %d modules failed to install", len(errs), len(result.Modules))
+	},
+}
 
-			newLock = append(newLock, core.LockEntry{
-				Name:          module,
-				Version:       version,
-				Resolved:      resolvedVersion,
-				Source:        "github",
-				Hash:          meta.Hash,
-				ResolvedTime:  meta.Time.Format(time.RFC3339),
-				InstalledTime: time.Now().UTC().Format(time.RFC3339),
-			})
-
-			table.Append([]string{module, version, resolvedVersion, status})
-			time.Sleep(100 * time.Millisecond)
+func renderInstallTable(report InstallReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Module", "Version", "Resolved", "Status"})
+	table.SetAutoWrapText(false)
+	table.SetBorder(true)
+	table.SetRowLine(true)
+
+	for _, row := range report.Modules {
+		resolved := orDash(row.Resolved)
+		if row.Status == StatusLinked {
+			resolved = row.Path
 		}
-
-		table.Render()
-
-		if err := core.WriteLockFile(newLock, globalFlag); err == nil {
-			fmt.Println("📌 Updated gopkg.lock")
+		status := statusLabel(row.Status)
+		if row.Error != "" {
+			status += " " + row.Error
 		}
-	},
+		table.Append([]string{row.Module, row.Declared, resolved, status})
+	}
+	table.Render()
+
+	if report.LockUpdated {
+		logf("📌 Updated gopkg.lock\n")
+	}
 }
 
 func init() {
-	installCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Install dependencies globally to ~/.gopkg/modules")
+	installCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Install dependencies globally to the gopkg home modules directory")
 	installCmd.Flags().
 		BoolVar(&autoFlag, "auto", false, "Automatically detect imports from Go files and update gopkg.toml")
+	installCmd.Flags().
+		BoolVar(&frozenFlag, "frozen", false, "Install exactly what gopkg.lock records and fail if it is out of date")
+	installCmd.Flags().IntVarP(&jobsFlag, "jobs", "j", 0, "Number of modules to download in parallel")
+	installCmd.Flags().BoolVar(&workFlag, "work", false, "Write replace directives to go.work instead of go.mod")
+	installCmd.Flags().BoolVarP(&workspaceFlag, "workspace", "w", false, "Install every [workspace] member together and write go.work")
 	rootCmd.AddCommand(installCmd)
 }
-
-func parseTime(t string) time.Time {
-	parsed, err := time.Parse(time.RFC3339, t)
-	if err != nil {
-		return time.Now().UTC()
-	}
-	return parsed
-}
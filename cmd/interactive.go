@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/term"
+
+	"github.com/pageton/gopkg/core"
+)
+
+type upgradeOption struct {
+	kind    core.UpdateKind
+	version string
+}
+
+type upgradeItem struct {
+	module   string
+	current  string
+	options  []upgradeOption
+	choice   int
+	selected bool
+	group    core.UpdateKind
+	// note explains updates that cannot be applied in place, such as a new
+	// major version under a different import path.
+	note string
+}
+
+func (it *upgradeItem) target() upgradeOption {
+	return it.options[it.choice]
+}
+
+var upgradeGroups = map[core.UpdateKind]int{core.UpdatePatch: 0, core.UpdateMinor: 1, core.UpdateMajor: 2}
+
+// upgradeItems turns an outdated report into selectable rows, grouped by the
+// largest in-place update. Each module can move to its latest patch, minor or
+// same-path major release.
+func upgradeItems(results []core.OutdatedResult, allow func(module, current, version string) bool) []*upgradeItem {
+	var items []*upgradeItem
+	for _, r := range results {
+		if r.Err != nil || r.Current == "" {
+			continue
+		}
+		it := &upgradeItem{module: r.Module, current: r.Current}
+		ok := func(v string) bool { return v != "" && allow(r.Module, r.Current, v) }
+		if ok(r.LatestPatch) {
+			it.options = append(it.options, upgradeOption{core.UpdatePatch, r.LatestPatch})
+		}
+		if ok(r.LatestMinor) && r.LatestMinor != r.LatestPatch {
+			it.options = append(it.options, upgradeOption{core.UpdateMinor, r.LatestMinor})
+		}
+		if r.LatestMajor != "" {
+			if r.MajorPath == "" {
+				if ok(r.LatestMajor) {
+					it.options = append(it.options, upgradeOption{core.UpdateMajor, r.LatestMajor})
+				}
+			} else {
+				it.note = fmt.Sprintf("%s@%s needs an import path change", r.MajorPath, r.LatestMajor)
+			}
+		}
+		if len(it.options) == 0 {
+			continue
+		}
+		it.choice = len(it.options) - 1
+		it.group = it.options[it.choice].kind
+		items = append(items, it)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return upgradeGroups[items[i].group] < upgradeGroups[items[j].group]
+	})
+	return items
+}
+
+type upgradeSelector struct {
+	items  []*upgradeItem
+	cursor int
+	lines  int
+}
+
+func (s *upgradeSelector) handle(key string) (done, cancelled bool) {
+	it := s.items[s.cursor]
+	switch key {
+	case "up", "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "j":
+		if s.cursor < len(s.items)-1 {
+			s.cursor++
+		}
+	case "left", "h":
+		if it.choice > 0 {
+			it.choice--
+		}
+	case "right", "l":
+		if it.choice < len(it.options)-1 {
+			it.choice++
+		}
+	case " ":
+		it.selected = !it.selected
+	case "a":
+		all := true
+		for _, i := range s.items {
+			all = all && i.selected
+		}
+		for _, i := range s.items {
+			i.selected = !all
+		}
+	case "enter":
+		return true, false
+	case "q", "esc", "ctrl-c":
+		return true, true
+	}
+	return false, false
+}
+
+func (s *upgradeSelector) view() string {
+	var b strings.Builder
+	b.WriteString(colorize(ansiBlue, "Select updates") + colorize(ansiGray, "  ↑/↓ move · space toggle · a all · ←/→ version · enter apply · q quit") + "\r\n")
+
+	var group core.UpdateKind
+	for i, it := range s.items {
+		if it.group != group {
+			group = it.group
+			b.WriteString("\r\n" + colorize(updateColors[group], strings.ToUpper(string(group))) + "\r\n")
+		}
+
+		cursor, box := "  ", "◯"
+		if i == s.cursor {
+			cursor = colorize(ansiCyan, "❯ ")
+		}
+		if it.selected {
+			box = colorize(ansiGreen, "◉")
+		}
+		opt := it.target()
+		fmt.Fprintf(&b, "%s%s %s %s → %s", cursor, box, it.module, it.current, colorize(updateColors[opt.kind], opt.version))
+		if len(it.options) > 1 {
+			b.WriteString(colorize(ansiGray, fmt.Sprintf(" (%s, %d/%d)", opt.kind, it.choice+1, len(it.options))))
+		}
+		b.WriteString("\r\n")
+		if i == s.cursor {
+			if hint := changelogHint(it.module, it.current, opt.version); hint != "" {
+				b.WriteString(colorize(ansiGray, "      "+hint) + "\r\n")
+			}
+			if it.note != "" {
+				b.WriteString(colorize(ansiGray, "      also available: "+it.note) + "\r\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func (s *upgradeSelector) draw(w io.Writer) {
+	if s.lines > 0 {
+		fmt.Fprintf(w, "\033[%dA\r\033[J", s.lines)
+	}
+	out := s.view()
+	s.lines = strings.Count(out, "\n")
+	fmt.Fprint(w, out)
+}
+
+// runUpgradeSelector shows the checklist on the terminal and returns the
+// chosen items, or nil when the user cancels.
+func runUpgradeSelector(items []*upgradeItem) ([]*upgradeItem, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	s := &upgradeSelector{items: items}
+	in := bufio.NewReader(os.Stdin)
+	fmt.Print("\033[?25l")
+	defer fmt.Print("\033[?25h")
+
+	for {
+		s.draw(os.Stdout)
+		key, err := readKey(in)
+		if err != nil {
+			return nil, err
+		}
+		if done, cancelled := s.handle(key); done {
+			if cancelled {
+				return nil, nil
+			}
+			break
+		}
+	}
+
+	var chosen []*upgradeItem
+	for _, it := range items {
+		if it.selected {
+			chosen = append(chosen, it)
+		}
+	}
+	return chosen, nil
+}
+
+func readKey(in *bufio.Reader) (string, error) {
+	b, err := in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case '\r', '\n':
+		return "enter", nil
+	case 3:
+		return "ctrl-c", nil
+	case 27:
+		if in.Buffered() == 0 {
+			return "esc", nil
+		}
+		if next, _ := in.ReadByte(); next != '[' && next != 'O' {
+			return "esc", nil
+		}
+		switch arrow, _ := in.ReadByte(); arrow {
+		case 'A':
+			return "up", nil
+		case 'B':
+			return "down", nil
+		case 'C':
+			return "right", nil
+		case 'D':
+			return "left", nil
+		}
+		return "", nil
+	}
+	return string(b), nil
+}
+
+// changelogHint links to the commit range between two releases for hosts
+// with a known compare URL, and to the module's version list otherwise.
+func changelogHint(path, from, to string) string {
+	prefix, _, _ := module.SplitPathVersion(path)
+	parts := strings.Split(prefix, "/")
+	if len(parts) >= 3 {
+		repo := strings.Join(parts[:3], "/")
+		tagPrefix := ""
+		if len(parts) > 3 {
+			tagPrefix = strings.Join(parts[3:], "/") + "/"
+		}
+		switch parts[0] {
+		case "github.com":
+			return fmt.Sprintf("https://%s/compare/%s%s...%s%s", repo, tagPrefix, from, tagPrefix, to)
+		case "gitlab.com":
+			return fmt.Sprintf("https://%s/-/compare/%s%s...%s%s", repo, tagPrefix, from, tagPrefix, to)
+		case "bitbucket.org":
+			return fmt.Sprintf("https://%s/branches/compare/%s%s%%0D%s%s", repo, tagPrefix, to, tagPrefix, from)
+		}
+	}
+	return fmt.Sprintf("https://pkg.go.dev/%s?tab=versions", path)
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pageton/gopkg/core"
+)
+
+func allowAll(module, current, version string) bool { return true }
+
+// describeItems renders items as "module group [kind@version ...] choice note".
+func describeItems(items []*upgradeItem) []string {
+	var out []string
+	for _, it := range items {
+		s := it.module + " " + string(it.group) + " ["
+		for i, o := range it.options {
+			if i > 0 {
+				s += " "
+			}
+			s += string(o.kind) + "@" + o.version
+		}
+		s += "] " + it.target().version
+		if it.note != "" {
+			s += " note: " + it.note
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestUpgradeItems(t *testing.T) {
+	results := []core.OutdatedResult{
+		{Module: "example.com/major", Current: "v0.4.0", LatestPatch: "v0.4.2", LatestMinor: "v0.6.0", LatestMajor: "v1.2.0"},
+		{Module: "example.com/minor", Current: "v1.2.0", LatestPatch: "v1.2.0", LatestMinor: "v1.5.0"},
+		{Module: "example.com/patch", Current: "v1.2.0", LatestPatch: "v1.2.3", LatestMinor: "v1.2.3"},
+		{Module: "example.com/v2path", Current: "v1.0.0", LatestPatch: "v1.0.1", LatestMinor: "v1.0.1", LatestMajor: "v2.1.0", MajorPath: "example.com/v2path/v2"},
+		{Module: "example.com/current", Current: "v1.0.0"},
+		{Module: "example.com/failed", Current: "v1.0.0", LatestPatch: "v1.0.1", Err: errors.New("boom")},
+		{Module: "example.com/unlocked", LatestPatch: "v1.0.1"},
+	}
+	got := describeItems(upgradeItems(results, allowAll))
+	want := []string{
+		"example.com/patch patch [patch@v1.2.3] v1.2.3",
+		"example.com/v2path patch [patch@v1.0.1] v1.0.1 note: example.com/v2path/v2@v2.1.0 needs an import path change",
+		"example.com/minor minor [patch@v1.2.0 minor@v1.5.0] v1.5.0",
+		"example.com/major major [patch@v0.4.2 minor@v0.6.0 major@v1.2.0] v1.2.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("upgradeItems =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestUpgradeItemsPolicy(t *testing.T) {
+	results := []core.OutdatedResult{
+		{Module: "example.com/a", Current: "v1.2.0", LatestPatch: "v1.2.3", LatestMinor: "v1.4.0", LatestMajor: "v2.0.0+incompatible"},
+		{Module: "example.com/pinned", Current: "v1.0.0", LatestPatch: "v1.0.1", LatestMinor: "v1.1.0"},
+		{Module: "example.com/ignored", Current: "v1.0.0", LatestPatch: "v1.0.1", LatestMinor: "v1.1.0"},
+	}
+	pol := &core.Policy{
+		Update: core.PolicyMinor,
+		Dependencies: map[string]core.DependencyPolicy{
+			"example.com/pinned":  {Update: core.PolicyNone},
+			"example.com/ignored": {Ignore: []string{"v1.1.0"}},
+		},
+	}
+	allow := func(module, current, version string) bool {
+		return pol.Allows(module, current, version, "")
+	}
+	got := describeItems(upgradeItems(results, allow))
+	want := []string{
+		"example.com/ignored patch [patch@v1.0.1] v1.0.1",
+		"example.com/a minor [patch@v1.2.3 minor@v1.4.0] v1.4.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("upgradeItems =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestUpgradeSelectorHandle(t *testing.T) {
+	items := upgradeItems([]core.OutdatedResult{
+		{Module: "example.com/a", Current: "v1.0.0", LatestPatch: "v1.0.1"},
+		{Module: "example.com/b", Current: "v1.0.0", LatestPatch: "v1.0.2", LatestMinor: "v1.3.0"},
+	}, allowAll)
+	s := &upgradeSelector{items: items}
+	selected := func() []bool {
+		var sel []bool
+		for _, it := range s.items {
+			sel = append(sel, it.selected)
+		}
+		return sel
+	}
+
+	steps := []struct {
+		key       string
+		cursor    int
+		selected  []bool
+		choiceB   int
+		done      bool
+		cancelled bool
+	}{
+		{"up", 0, []bool{false, false}, 1, false, false},
+		{" ", 0, []bool{true, false}, 1, false, false},
+		{"down", 1, []bool{true, false}, 1, false, false},
+		{"down", 1, []bool{true, false}, 1, false, false},
+		{"left", 1, []bool{true, false}, 0, false, false},
+		{"left", 1, []bool{true, false}, 0, false, false},
+		{"l", 1, []bool{true, false}, 1, false, false},
+		{"right", 1, []bool{true, false}, 1, false, false},
+		{"a", 1, []bool{true, true}, 1, false, false},
+		{"a", 1, []bool{false, false}, 1, false, false},
+		{"k", 0, []bool{false, false}, 1, false, false},
+		{"a", 0, []bool{true, true}, 1, false, false},
+		{"x", 0, []bool{true, true}, 1, false, false},
+		{"enter", 0, []bool{true, true}, 1, true, false},
+		{"q", 0, []bool{true, true}, 1, true, true},
+		{"ctrl-c", 0, []bool{true, true}, 1, true, true},
+	}
+	for i, st := range steps {
+		done, cancelled := s.handle(st.key)
+		if s.cursor != st.cursor || !reflect.DeepEqual(selected(), st.selected) || s.items[1].choice != st.choiceB || done != st.done || cancelled != st.cancelled {
+			t.Fatalf("step %d (%q): cursor %d, selected %v, choice %d, done %v, cancelled %v", i, st.key, s.cursor, selected(), s.items[1].choice, done, cancelled)
+		}
+	}
+	if got := s.items[1].target().version; got != "v1.3.0" {
+		t.Errorf("target = %s", got)
+	}
+}
+
+func TestChangelogHint(t *testing.T) {
+	tests := []struct {
+		path, from, to string
+		want           string
+	}{
+		{"github.com/spf13/cobra", "v1.8.0", "v1.9.1", "https://github.com/spf13/cobra/compare/v1.8.0...v1.9.1"},
+		{"github.com/jackc/pgx/v5", "v5.5.0", "v5.7.1", "https://github.com/jackc/pgx/compare/v5.5.0...v5.7.1"},
+		{"github.com/aws/aws-sdk-go-v2/service/s3", "v1.50.0", "v1.58.2", "https://github.com/aws/aws-sdk-go-v2/compare/service/s3/v1.50.0...service/s3/v1.58.2"},
+		{"github.com/example/mono/tools/v2", "v2.0.0", "v2.1.0", "https://github.com/example/mono/compare/tools/v2.0.0...tools/v2.1.0"},
+		{"gitlab.com/group/project/sub", "v0.1.0", "v0.2.0", "https://gitlab.com/group/project/-/compare/sub/v0.1.0...sub/v0.2.0"},
+		{"bitbucket.org/team/repo", "v1.0.0", "v1.1.0", "https://bitbucket.org/team/repo/branches/compare/v1.1.0%0Dv1.0.0"},
+		{"golang.org/x/sys", "v0.20.0", "v0.25.0", "https://pkg.go.dev/golang.org/x/sys?tab=versions"},
+		{"github.com/short", "v1.0.0", "v1.0.1", "https://pkg.go.dev/github.com/short?tab=versions"},
+	}
+	for _, tt := range tests {
+		if got := changelogHint(tt.path, tt.from, tt.to); got != tt.want {
+			t.Errorf("changelogHint(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var licensesCSVFlag bool
+
+var licensesCmd = &cobra.Command{
+	Use:   "licenses",
+	Short: "Show the license of each installed module",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		results, err := project.Licenses()
+		if err != nil {
+			return err
+		}
+		cfg, err := project.LoadManifest()
+		if err != nil {
+			return err
+		}
+
+		report := LicensesReport{Modules: []LicenseRow{}, Policy: cfg.Policy.HasLicenseRules()}
+		var errs []error
+		violations := 0
+		for _, r := range results {
+			row := LicenseRow{
+				Module:    r.Module,
+				Version:   r.Version,
+				License:   r.License(),
+				Licenses:  r.Licenses,
+				Files:     r.Files,
+				Violation: r.Violation,
+			}
+			if r.Err != nil {
+				row.License = ""
+				row.Error = r.Err.Error()
+				errs = append(errs, r.Err)
+			}
+			if r.Violation != "" {
+				violations++
+			}
+			report.Modules = append(report.Modules, row)
+		}
+
+		if licensesCSVFlag {
+			if err := writeLicensesCSV(report); err != nil {
+				return err
+			}
+		} else {
+			render(report, func() { renderLicensesTable(report) })
+		}
+		if violations > 0 {
+			return core.NewError(core.ErrPolicy, "%d modules violate the license policy", violations)
+		}
+		return failures(errs, "%d modules are not installed", len(errs))
+	},
+}
+
+func writeLicensesCSV(report LicensesReport) error {
+	w := csv.NewWriter(os.Stdout)
+	_ = w.Write([]string{"module", "version", "license", "files", "violation"})
+	for _, row := range report.Modules {
+		license := row.License
+		if row.Error != "" {
+			license = row.Error
+		}
+		_ = w.Write([]string{row.Module, row.Version, license, strings.Join(row.Files, " "), row.Violation})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func renderLicensesTable(report LicensesReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Module", "Version", "License", "Files", "Policy"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for _, row := range report.Modules {
+		license, policy := row.License, "—"
+		switch {
+		case row.Error != "":
+			license, policy = colorize(ansiRed, "✖️ "+row.Error), "—"
+		case row.Violation != "":
+			policy = colorize(ansiRed, "✖️ "+row.Violation)
+		case report.Policy:
+			policy = colorize(ansiGreen, "✔️ Allowed")
+		}
+		if license == core.UnknownLicense {
+			license = colorize(ansiYellow, license)
+		}
+		table.Append([]string{row.Module, orDash(row.Version), license, orDash(strings.Join(row.Files, ", ")), policy})
+	}
+	fmt.Println("\n" + colorize(ansiBlue, "📜 Licenses:"))
+	table.Render()
+}
+
+func init() {
+	licensesCmd.Flags().BoolVar(&licensesCSVFlag, "csv", false, "Print CSV instead of a table")
+	licensesCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Show licenses of global modules")
+	rootCmd.AddCommand(licensesCmd)
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link [path|module]",
+	Short: "Link a local module into the project instead of the installed version",
+	Example: `
+  gopkg link ../mylib
+  gopkg link                       # register the current module globally
+  gopkg link github.com/user/mylib # link a globally registered module
+`,
+	Args: usageArgs(cobra.MaximumNArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		registry := project.Layout.LinkRegistryPath()
+
+		if len(args) == 0 {
+			wd := core.GetCurrentDir()
+			module, err := core.ReadModulePath(wd)
+			if err != nil {
+				return err
+			}
+
+			reg, err := core.LoadLinkRegistry(registry)
+			if err != nil {
+				return err
+			}
+			reg.Links[module] = wd
+			if err := core.SaveLinkRegistry(registry, reg); err != nil {
+				return fmt.Errorf("failed to save link registry: %w", err)
+			}
+			successf("Registered %s -> %s", module, wd)
+			infof("Run `gopkg link %s` in another project to use it", module)
+			return nil
+		}
+
+		target := args[0]
+		var module, linkPath string
+		if info, err := os.Stat(target); err == nil && info.IsDir() {
+			module, err = core.ReadModulePath(target)
+			if err != nil {
+				return err
+			}
+			linkPath = core.NormalizeLinkPath(target)
+		} else {
+			reg, err := core.LoadLinkRegistry(registry)
+			if err != nil {
+				return err
+			}
+			path, ok := reg.Links[target]
+			if !ok {
+				return &core.ModuleError{
+					Module: target,
+					Kind:   core.ErrNotFound,
+					Err:    fmt.Errorf("neither a directory nor a registered module (run `gopkg link` inside the module to register it)"),
+				}
+			}
+			module, linkPath = target, path
+		}
+
+		result, err := project.Link(cmd.Context(), module, linkPath)
+		if err := linkFailure(result, err); err != nil {
+			return err
+		}
+		for _, m := range result.Modules {
+			if m.Module == module && m.Path != "" {
+				linkPath = m.Path
+			}
+		}
+		logf("%s\n", colorize(ansiGreen, fmt.Sprintf("🔗 Linked %s -> %s", module, linkPath)))
+		return nil
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink <module>",
+	Short: "Remove a local link and restore the locked version",
+	Example: `
+  gopkg unlink github.com/user/mylib
+`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		module := args[0]
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+
+		result, err := project.Unlink(cmd.Context(), module)
+		if err := linkFailure(result, err); err != nil {
+			return err
+		}
+		for _, m := range result.Modules {
+			if m.Module == module && m.Resolved != "" {
+				successf("Unlinked %s, restored %s", module, m.Resolved)
+				return nil
+			}
+		}
+		successf("Unlinked %s", module)
+		return nil
+	},
+}
+
+// linkFailure turns a link or unlink result into the command's error.
+func linkFailure(result *core.InstallResult, err error) error {
+	if err != nil || result == nil {
+		return err
+	}
+	errs := result.Errors()
+	return failures(errs, "%d of %d modules failed to install", len(errs), len(result.Modules))
+}
+
+func init() {
+	linkCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Link into the global gopkg.toml")
+	unlinkCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Unlink from the global gopkg.toml")
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+}
@@ -14,56 +14,97 @@ import (
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all dependencies",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		tomlPath := core.GetTomlPath(globalFlag)
 		cfg, err := core.LoadToml(tomlPath)
 		if err != nil {
-			fmt.Printf("\033[31m✖️ Failed to load %s: %v\033[0m\n", tomlPath, err)
-			return
+			return fmt.Errorf("failed to load %s: %w", tomlPath, err)
 		}
 
-		lockMap := map[string]core.LockEntry{}
-		locks, _ := core.LoadLockFile(globalFlag)
-		for _, entry := range locks {
-			lockMap[entry.Name] = entry
-		}
+		report := collectList(cfg)
+		render(report, func() { renderListTable(report) })
+		return nil
+	},
+}
+
+func collectList(cfg *core.GopkgToml) ModulesReport {
+	lockMap := map[string]core.LockEntry{}
+	locks, _ := core.LoadLockFile(globalFlag)
+	for _, entry := range locks {
+		lockMap[entry.Name] = entry
+	}
 
-		modules := make([]string, 0, len(cfg.Dependencies))
-		for m := range cfg.Dependencies {
+	modules := make([]string, 0, len(cfg.Dependencies))
+	for m := range cfg.Dependencies {
+		modules = append(modules, m)
+	}
+	for m := range cfg.Links {
+		if _, ok := cfg.Dependencies[m]; !ok {
 			modules = append(modules, m)
 		}
-		sort.Strings(modules)
+	}
+	sort.Strings(modules)
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Module", "Declared", "Locked", "Status"})
-		table.SetRowLine(true)
-		table.SetAutoWrapText(false)
+	report := ModulesReport{Modules: []ModuleReport{}}
+	for _, module := range modules {
+		row := ModuleReport{
+			Module:   module,
+			Declared: cfg.Dependencies[module],
+			Status:   StatusNotInstalled,
+		}
 
-		rows := [][]string{}
-		for _, module := range modules {
-			declared := cfg.Dependencies[module]
-			locked := "—"
-			status := "\033[31m✖️ Not installed\033[0m"
+		if linkPath, ok := cfg.Links[module]; ok {
+			row.Path = linkPath
+			row.Status = StatusLinked
+		} else if lock, ok := lockMap[module]; ok {
+			row.Locked = lock.Resolved
+			row.Status = lockStatus(row.Locked, row.Declared)
+		}
 
-			if lock, ok := lockMap[module]; ok {
-				locked = lock.Resolved
-				cmp := core.CompareVersions(locked, declared)
-				switch cmp {
-				case 0:
-					status = "\033[32m✔️ Up-to-date\033[0m"
-				case -1:
-					status = "\033[33m⚠️  Outdated\033[0m"
-				default:
-					status = "\033[34mℹ️  Ahead\033[0m"
-				}
-			}
+		report.Modules = append(report.Modules, row)
+	}
+	return report
+}
 
-			rows = append(rows, []string{module, declared, locked, status})
+func lockStatus(locked, declared string) ModuleStatus {
+	c, err := core.ParseConstraint(declared)
+	if _, exact := c.Exact(); err == nil && !exact {
+		if c.Match(locked) {
+			return StatusUpToDate
 		}
+		return StatusOutdated
+	}
+	switch core.CompareVersions(locked, declared) {
+	case 0:
+		return StatusUpToDate
+	case -1:
+		return StatusOutdated
+	default:
+		return StatusAhead
+	}
+}
 
-		table.AppendBulk(rows)
-		table.Render()
-	},
+func renderListTable(report ModulesReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Module", "Declared", "Locked", "Status"})
+	table.SetRowLine(true)
+	table.SetAutoWrapText(false)
+
+	for _, row := range report.Modules {
+		locked := orDash(row.Locked)
+		if row.Status == StatusLinked {
+			locked = row.Path
+		}
+		table.Append([]string{row.Module, orDash(row.Declared), locked, statusLabel(row.Status)})
+	}
+	table.Render()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
 }
 
 func init() {
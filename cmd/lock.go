@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+const mergeDriverName = "gopkg-lock"
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Inspect and merge gopkg.lock",
+}
+
+var lockDiffCmd = &cobra.Command{
+	Use:   "diff [<from-ref> [<to-ref>]]",
+	Short: "Show how gopkg.lock differs from another git revision",
+	Long: `Compare gopkg.lock between git revisions. With no arguments the working
+copy is compared with HEAD; with one, with that revision; with two, the
+revisions are compared with each other.`,
+	Example: `
+  gopkg lock diff
+  gopkg lock diff origin/main
+  gopkg lock diff v1.2.0 v1.3.0
+`,
+	Args: usageArgs(cobra.MaximumNArgs(2)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		lockPath := project.Layout.LockPath()
+
+		report := LockDiffReport{From: "HEAD", To: "working tree"}
+		if len(args) > 0 {
+			report.From = args[0]
+		}
+		before, err := core.LockAtRevision(lockPath, report.From)
+		if err != nil {
+			return err
+		}
+		var after []core.LockEntry
+		if len(args) == 2 {
+			report.To = args[1]
+			after, err = core.LockAtRevision(lockPath, report.To)
+		} else {
+			after, err = project.LoadLock()
+		}
+		if err != nil {
+			return err
+		}
+
+		report.Changes = core.DiffLocks(before, after)
+		render(report, func() {
+			if len(report.Changes) == 0 {
+				successf("gopkg.lock is the same in %s and %s.", report.From, report.To)
+				return
+			}
+			fmt.Printf("%s\n\n", colorize(ansiBlue, fmt.Sprintf("gopkg.lock: %s → %s", report.From, report.To)))
+			renderLockDiffTable(report.Changes)
+		})
+		return nil
+	},
+}
+
+func renderLockDiffTable(changes []core.LockChange) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Module", "Before", "After", "Change", "Severity"})
+	table.SetAutoWrapText(false)
+	for _, c := range changes {
+		kind := colorize(changeColors[c.Kind], string(c.Kind))
+		if c.SumChanged {
+			kind = colorize(ansiRed, "checksum changed")
+		}
+		severity := "—"
+		if c.Severity != core.UpdateNone {
+			severity = colorize(updateColors[c.Severity], string(c.Severity))
+		}
+		name := c.Module
+		if c.Indirect {
+			name += colorize(ansiGray, " (indirect)")
+		}
+		table.Append([]string{name, orDash(c.Before), orDash(c.After), kind, severity})
+	}
+	table.Render()
+}
+
+var lockMergeCmd = &cobra.Command{
+	Use:   "merge <base> <ours> <theirs>",
+	Short: "Three-way merge of gopkg.lock, for use as a git merge driver",
+	Long: `Merge two versions of gopkg.lock against their common ancestor and write
+the result to <ours>, as git expects from a merge driver. Modules changed on
+both sides to different versions are conflicts: they keep our entry, are
+listed, and the command exits with code 6 so git reports the file as
+conflicted. Run 'gopkg install' afterwards so the lock matches gopkg.toml
+again.`,
+	Args: usageArgs(cobra.ExactArgs(3)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sides [3]*core.LockFile
+		for i, path := range args {
+			lock, err := core.ReadLockFileAt(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			sides[i] = lock
+		}
+
+		merge := core.MergeLocks(sides[0], sides[1], sides[2])
+		for _, note := range merge.Notes {
+			warnf("gopkg.lock: %s", note)
+		}
+		if err := core.SaveLockFileAt(args[1], merge.Lock); err != nil {
+			return err
+		}
+		if len(merge.Conflicts) == 0 {
+			return nil
+		}
+		for _, conflict := range merge.Conflicts {
+			warnf("gopkg.lock: %s", conflict)
+		}
+		return core.NewError(core.ErrConflict, "%d modules changed on both sides, kept ours: pick the versions in gopkg.toml and run `gopkg install`", len(merge.Conflicts))
+	},
+}
+
+var lockMergeDriverCmd = &cobra.Command{
+	Use:   "install-merge-driver",
+	Short: "Register 'gopkg lock merge' as the git merge driver for gopkg.lock",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config := [][]string{
+			{"merge." + mergeDriverName + ".name", "gopkg lockfile merge driver"},
+			{"merge." + mergeDriverName + ".driver", "gopkg lock merge %O %A %B"},
+		}
+		for _, kv := range config {
+			if out, err := exec.Command("git", "config", kv[0], kv[1]).CombinedOutput(); err != nil {
+				return fmt.Errorf("git config %s: %s", kv[0], strings.TrimSpace(string(out)))
+			}
+		}
+		successf("Registered the %s merge driver in .git/config", mergeDriverName)
+
+		project, err := openProject(false)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(project.Layout.Root, ".gitattributes")
+		line := filepath.Base(project.Layout.LockPath()) + " merge=" + mergeDriverName
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, l := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(l) == line {
+				infof("%s already routes gopkg.lock to the driver", path)
+				return nil
+			}
+		}
+		if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+			data = append(data, '\n')
+		}
+		data = append(data, line+"\n"...)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		successf("Added %q to %s", line, path)
+		return nil
+	},
+}
+
+func init() {
+	lockDiffCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Diff the global gopkg.lock")
+	lockCmd.AddCommand(lockDiffCmd, lockMergeCmd, lockMergeDriverCmd)
+	rootCmd.AddCommand(lockCmd)
+}
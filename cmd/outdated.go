@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Show available patch, minor and major updates",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		results, err := project.Outdated(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		report := OutdatedReport{Modules: []OutdatedRow{}}
+		var errs []error
+		for _, r := range results {
+			row := OutdatedRow{
+				Module:      r.Module,
+				Declared:    r.Declared,
+				Current:     r.Current,
+				Wanted:      r.Wanted,
+				LatestPatch: r.LatestPatch,
+				LatestMinor: r.LatestMinor,
+				LatestMajor: r.LatestMajor,
+				MajorPath:   r.MajorPath,
+				Update:      r.Update,
+			}
+			if r.Err != nil {
+				row.Error = r.Err.Error()
+				errs = append(errs, r.Err)
+			}
+			report.Modules = append(report.Modules, row)
+		}
+
+		render(report, func() { renderOutdatedTable(report) })
+		return failures(errs, "failed to check %d of %d modules", len(errs), len(report.Modules))
+	},
+}
+
+var updateColors = map[core.UpdateKind]string{
+	core.UpdateMajor: ansiRed,
+	core.UpdateMinor: ansiYellow,
+	core.UpdatePatch: ansiGreen,
+}
+
+func renderOutdatedTable(report OutdatedReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Module", "Current", "Wanted", "Latest patch", "Latest minor", "Latest major", "Update"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for _, row := range report.Modules {
+		major := orDash(row.LatestMajor)
+		if row.MajorPath != "" {
+			major = row.MajorPath + "@" + row.LatestMajor
+		}
+		update := string(row.Update)
+		if row.Error != "" {
+			update = colorize(ansiRed, "✖️ "+row.Error)
+		}
+
+		color := updateColors[row.Update]
+		cells := []string{row.Module, orDash(row.Current), orDash(row.Wanted), orDash(row.LatestPatch), orDash(row.LatestMinor), major}
+		for i := range cells {
+			cells[i] = colorize(color, cells[i])
+		}
+		if row.Error == "" {
+			update = colorize(color, update)
+		}
+		table.Append(append(cells, update))
+	}
+
+	fmt.Println("\n" + colorize(ansiBlue, "📋 Outdated dependencies:"))
+	table.Render()
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Check the global gopkg.toml")
+	rootCmd.AddCommand(outdatedCmd)
+}
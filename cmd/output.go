@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pageton/gopkg/core"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormat string
+
+type ModuleStatus = core.ModuleStatus
+
+const (
+	StatusUpToDate        = core.StatusUpToDate
+	StatusOutdated        = core.StatusOutdated
+	StatusAhead           = core.StatusAhead
+	StatusNotInstalled    = core.StatusNotInstalled
+	StatusLinked          = core.StatusLinked
+	StatusLocked          = core.StatusLocked
+	StatusInstalled       = core.StatusInstalled
+	StatusUpdateAvailable = core.StatusUpdateAvailable
+	StatusUpdated         = core.StatusUpdated
+	StatusFailed          = core.StatusFailed
+	StatusPinned          = core.StatusPinned
+)
+
+type ModuleReport struct {
+	Module   string       `json:"module" yaml:"module"`
+	Declared string       `json:"declared,omitempty" yaml:"declared,omitempty"`
+	Locked   string       `json:"locked,omitempty" yaml:"locked,omitempty"`
+	Resolved string       `json:"resolved,omitempty" yaml:"resolved,omitempty"`
+	Latest   string       `json:"latest,omitempty" yaml:"latest,omitempty"`
+	Path     string       `json:"path,omitempty" yaml:"path,omitempty"`
+	Status   ModuleStatus `json:"status" yaml:"status"`
+	Error    string       `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type ModulesReport struct {
+	Modules []ModuleReport `json:"modules" yaml:"modules"`
+}
+
+type InstallReport struct {
+	Modules     []ModuleReport `json:"modules" yaml:"modules"`
+	LockUpdated bool           `json:"lock_updated" yaml:"lock_updated"`
+}
+
+type VersionInfo struct {
+	Version string `json:"version" yaml:"version"`
+	Note    string `json:"note" yaml:"note"`
+}
+
+type VersionsReport struct {
+	Module   string        `json:"module" yaml:"module"`
+	Versions []VersionInfo `json:"versions" yaml:"versions"`
+}
+
+type ModuleRef struct {
+	Module  string `json:"module" yaml:"module"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+func (r ModuleRef) String() string {
+	switch {
+	case r.Path != "":
+		return r.Module + " => " + r.Path
+	case r.Version != "":
+		return r.Module + "@" + r.Version
+	default:
+		return r.Module
+	}
+}
+
+func sortRefs(refs []ModuleRef) {
+	sort.Slice(refs, func(i, j int) bool { return refs[i].String() < refs[j].String() })
+}
+
+type WhyPath struct {
+	Path     []ModuleRef `json:"path" yaml:"path"`
+	Requires string      `json:"requires" yaml:"requires"`
+	Selected bool        `json:"selected" yaml:"selected"`
+}
+
+type WhyReport struct {
+	Module     string      `json:"module" yaml:"module"`
+	Selected   string      `json:"selected" yaml:"selected"`
+	SelectedBy []ModuleRef `json:"selected_by" yaml:"selected_by"`
+	Paths      []WhyPath   `json:"paths" yaml:"paths"`
+	// Truncated is set when more paths exist than --limit allows.
+	Truncated bool `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+}
+
+type TreeNode struct {
+	Module    string     `json:"module" yaml:"module"`
+	Version   string     `json:"version,omitempty" yaml:"version,omitempty"`
+	Path      string     `json:"path,omitempty" yaml:"path,omitempty"`
+	Selected  string     `json:"selected,omitempty" yaml:"selected,omitempty"`
+	Deduped   bool       `json:"deduped,omitempty" yaml:"deduped,omitempty"`
+	Truncated bool       `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+	Children  []TreeNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+type TreeReport struct {
+	Name         string     `json:"name" yaml:"name"`
+	Dependencies []TreeNode `json:"dependencies" yaml:"dependencies"`
+}
+
+type GraphNode struct {
+	ID       string `json:"id" yaml:"id"`
+	Module   string `json:"module" yaml:"module"`
+	Version  string `json:"version,omitempty" yaml:"version,omitempty"`
+	Path     string `json:"path,omitempty" yaml:"path,omitempty"`
+	Selected bool   `json:"selected" yaml:"selected"`
+	Direct   bool   `json:"direct" yaml:"direct"`
+}
+
+type GraphEdge struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+type GraphReport struct {
+	Name  string      `json:"name" yaml:"name"`
+	Nodes []GraphNode `json:"nodes" yaml:"nodes"`
+	Edges []GraphEdge `json:"edges" yaml:"edges"`
+}
+
+type OutdatedRow struct {
+	Module      string          `json:"module" yaml:"module"`
+	Declared    string          `json:"declared" yaml:"declared"`
+	Current     string          `json:"current,omitempty" yaml:"current,omitempty"`
+	Wanted      string          `json:"wanted,omitempty" yaml:"wanted,omitempty"`
+	LatestPatch string          `json:"latest_patch,omitempty" yaml:"latest_patch,omitempty"`
+	LatestMinor string          `json:"latest_minor,omitempty" yaml:"latest_minor,omitempty"`
+	LatestMajor string          `json:"latest_major,omitempty" yaml:"latest_major,omitempty"`
+	MajorPath   string          `json:"major_path,omitempty" yaml:"major_path,omitempty"`
+	Update      core.UpdateKind `json:"update" yaml:"update"`
+	Error       string          `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type OutdatedReport struct {
+	Modules []OutdatedRow `json:"modules" yaml:"modules"`
+}
+
+type ConfigReport struct {
+	Settings []core.ConfigValue `json:"settings" yaml:"settings"`
+}
+
+type SnapshotsReport struct {
+	Snapshots []core.Snapshot `json:"snapshots" yaml:"snapshots"`
+}
+
+type RollbackReport struct {
+	Snapshot core.Snapshot  `json:"snapshot" yaml:"snapshot"`
+	Modules  []ModuleReport `json:"modules" yaml:"modules"`
+}
+
+type HistoryReport struct {
+	Entries []core.HistoryEntry `json:"entries" yaml:"entries"`
+}
+
+type LockDiffReport struct {
+	From    string            `json:"from" yaml:"from"`
+	To      string            `json:"to" yaml:"to"`
+	Changes []core.LockChange `json:"changes" yaml:"changes"`
+}
+
+type AuditReport struct {
+	Database        string               `json:"database" yaml:"database"`
+	Vulnerabilities []core.Vulnerability `json:"vulnerabilities" yaml:"vulnerabilities"`
+	Fixes           []core.AuditFix      `json:"fixes,omitempty" yaml:"fixes,omitempty"`
+	Modules         []ModuleReport       `json:"modules,omitempty" yaml:"modules,omitempty"`
+	// Remaining counts vulnerabilities still present after --fix.
+	Remaining int `json:"remaining" yaml:"remaining"`
+}
+
+type LicenseRow struct {
+	Module    string   `json:"module" yaml:"module"`
+	Version   string   `json:"version,omitempty" yaml:"version,omitempty"`
+	License   string   `json:"license" yaml:"license"`
+	Licenses  []string `json:"licenses" yaml:"licenses"`
+	Files     []string `json:"files" yaml:"files"`
+	Violation string   `json:"violation,omitempty" yaml:"violation,omitempty"`
+	Error     string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type LicensesReport struct {
+	// Policy is set when gopkg.toml has license rules.
+	Policy  bool         `json:"policy" yaml:"policy"`
+	Modules []LicenseRow `json:"modules" yaml:"modules"`
+}
+
+type ErrorReport struct {
+	Error string `json:"error" yaml:"error"`
+	Kind  string `json:"kind" yaml:"kind"`
+	Code  int    `json:"code" yaml:"code"`
+}
+
+type statusStyle struct {
+	color string
+	text  string
+}
+
+var statusLabels = map[ModuleStatus]statusStyle{
+	StatusUpToDate:        {ansiGreen, "✔️ Up-to-date"},
+	StatusOutdated:        {ansiYellow, "⚠️  Outdated"},
+	StatusAhead:           {ansiBlue, "ℹ️  Ahead"},
+	StatusNotInstalled:    {ansiRed, "✖️ Not installed"},
+	StatusLinked:          {ansiCyan, "🔗 Linked"},
+	StatusLocked:          {"", "Locked"},
+	StatusInstalled:       {"", "Installed"},
+	StatusUpdateAvailable: {ansiYellow, "Update available"},
+	StatusUpdated:         {ansiGreen, "Updated"},
+	StatusFailed:          {ansiRed, "✖️ Failed"},
+	StatusPinned:          {ansiGray, "📌 Pinned"},
+}
+
+func statusLabel(s ModuleStatus) string {
+	if style, ok := statusLabels[s]; ok {
+		return colorize(style.color, style.text)
+	}
+	return string(s)
+}
+
+func moduleReport(r core.ModuleResult) ModuleReport {
+	row := ModuleReport{
+		Module:   r.Module,
+		Declared: r.Declared,
+		Locked:   r.Locked,
+		Resolved: r.Resolved,
+		Latest:   r.Latest,
+		Path:     r.Path,
+		Status:   r.Status,
+	}
+	if r.Err != nil {
+		row.Error = r.Err.Error()
+	}
+	return row
+}
+
+func validateOutput() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	default:
+		return usageErrorf("invalid --output %q (use table, json or yaml)", outputFormat)
+	}
+}
+
+func structuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+func render(v any, table func()) {
+	renderTo(os.Stdout, v, table)
+}
+
+func renderTo(w io.Writer, v any, table func()) {
+	switch outputFormat {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		_ = enc.Encode(v)
+		_ = enc.Close()
+	default:
+		table()
+	}
+}
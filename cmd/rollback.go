@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var rollbackList bool
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [snapshot]",
+	Short: "Restore gopkg.toml, gopkg.lock, go.mod and go.sum from a snapshot",
+	Example: `
+  gopkg rollback
+  gopkg rollback --list
+  gopkg rollback 20250101T120000.000000000
+`,
+	Args: usageArgs(cobra.MaximumNArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+
+		if rollbackList {
+			snaps, err := project.Snapshots()
+			if err != nil {
+				return err
+			}
+			report := SnapshotsReport{Snapshots: append([]core.Snapshot{}, snaps...)}
+			render(report, func() {
+				if len(report.Snapshots) == 0 {
+					infof("No snapshots yet.")
+					return
+				}
+				table := tablewriter.NewWriter(os.Stdout)
+				table.SetHeader([]string{"Snapshot", "Command", "Taken", "Files"})
+				table.SetAutoWrapText(false)
+				for _, s := range report.Snapshots {
+					table.Append([]string{s.ID, s.Command, s.Time.Local().Format(time.DateTime), strings.Join(s.Files, ", ")})
+				}
+				table.Render()
+			})
+			return nil
+		}
+
+		var id string
+		if len(args) == 1 {
+			id = args[0]
+		}
+		snap, result, err := project.Rollback(cmd.Context(), id)
+		if snap == nil {
+			return err
+		}
+
+		report := RollbackReport{Snapshot: *snap, Modules: []ModuleReport{}}
+		if result != nil {
+			for _, m := range result.Modules {
+				report.Modules = append(report.Modules, moduleReport(m))
+			}
+		}
+		render(report, func() {
+			successf("Restored the state before `%s` from %s", snap.Command, snap.Time.Local().Format(time.DateTime))
+			if len(report.Modules) > 0 {
+				renderInstallTable(InstallReport{Modules: report.Modules, LockUpdated: result.LockUpdated})
+			}
+		})
+		if err != nil || result == nil {
+			return err
+		}
+		errs := result.Errors()
+		return failures(errs, "%d of %d modules failed to reinstall", len(errs), len(result.Modules))
+	},
+}
+
+func init() {
+	rollbackCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Roll back the global gopkg.toml")
+	rollbackCmd.Flags().BoolVar(&rollbackList, "list", false, "List available snapshots, newest first")
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -1,14 +1,132 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
 	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "gopkg",
-	Short: "Gopkg is a dependency manager for Go modules",
+	Use:               "gopkg",
+	Short:             "Gopkg is a dependency manager for Go modules",
+	PersistentPreRunE: setup,
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+}
+
+var (
+	homeFlag  string
+	proxyFlag string
+	colorFlag string
+)
+
+func setup(cmd *cobra.Command, args []string) error {
+	if homeFlag != "" {
+		home, err := filepath.Abs(homeFlag)
+		if err != nil {
+			return usageErrorf("invalid --home %q: %v", homeFlag, err)
+		}
+		// Exported so helpers and child processes resolve the same layout.
+		os.Setenv("GOPKG_HOME", home)
+	}
+	if err := setupConfig(cmd); err != nil {
+		return err
+	}
+	return setupUI(cmd, args)
+}
+
+var (
+	// cliConfig is the configuration of the current directory, used for
+	// output settings and `gopkg config`.
+	cliConfig *core.Config
+	// configOverrides are the settings given as flags, applied on top of
+	// every project's configuration.
+	configOverrides []core.ConfigValue
+)
+
+// setupConfig loads the layered configuration and puts flags on top of it.
+func setupConfig(cmd *cobra.Command) error {
+	cfg, err := core.LoadConfig(core.DefaultLayout(false))
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	flags := cmd.Flags()
+	overrides := []struct{ flag, key, value string }{
+		{"proxy", "proxy", proxyFlag},
+		{"color", "color", colorFlag},
+		{"jobs", "jobs", strconv.Itoa(jobsFlag)},
+	}
+	if f := flags.Lookup("work"); f != nil && f.Changed {
+		target := "go.mod"
+		if workFlag {
+			target = "go.work"
+		}
+		overrides = append(overrides, struct{ flag, key, value string }{"work", "replaces", target})
+	}
+	configOverrides = nil
+	for _, o := range overrides {
+		if f := flags.Lookup(o.flag); f != nil && f.Changed {
+			v := core.ConfigValue{Key: o.key, Value: o.value, Source: core.SourceFlag, Origin: "--" + o.flag}
+			if err := cfg.Override(v.Key, v.Value, v.Source, v.Origin); err != nil {
+				return usageErrorf("%v", err)
+			}
+			configOverrides = append(configOverrides, v)
+		}
+	}
+
+	if f := flags.Lookup("global"); f != nil {
+		if f.Changed {
+			mode := "local"
+			if globalFlag {
+				mode = "global"
+			}
+			_ = cfg.Override("install_mode", mode, core.SourceFlag, "--global")
+		} else {
+			globalFlag = cfg.InstallMode() == "global"
+		}
+	}
+
+	cliConfig = cfg
+	return nil
+}
+
+// openProject returns the local or global project with its own configuration
+// and the flags on top.
+func openProject(global bool) (*core.Project, error) {
+	project, err := core.LoadProject(core.DefaultLayout(global), configOverrides...)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+	return project, nil
 }
 
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
+		os.Exit(reportError(err))
+	}
+}
+
+func init() {
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &usageError{err}
+	})
+	rootCmd.PersistentFlags().
+		StringVarP(&outputFormat, "output", "o", outputTable, "Output format: table, json or yaml")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Only print results, warnings and errors")
+	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "Print resolution, cache and extraction details")
+	rootCmd.PersistentFlags().
+		StringVar(&homeFlag, "home", "", "gopkg home directory for global modules, cache and config (overrides GOPKG_HOME)")
+	rootCmd.PersistentFlags().StringVar(&proxyFlag, "proxy", "", "Module proxy URL (overrides config and GOPKG_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", "", "Color output: auto, always or never")
 }
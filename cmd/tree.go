@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/module"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var (
+	treeDepth   int
+	treeOffline bool
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show the resolved dependency tree",
+	Example: `
+  gopkg tree
+  gopkg tree --depth 2
+`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if treeDepth < 0 {
+			return usageErrorf("--depth must be 0 (unlimited) or more")
+		}
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		graph, err := project.LoadGraph(cmd.Context(), core.GraphOptions{Offline: treeOffline})
+		if err != nil {
+			return err
+		}
+		warnMissing(graph)
+
+		report := TreeReport{Name: graph.Name, Dependencies: []TreeNode{}}
+		expanded := map[module.Version]bool{}
+		for _, root := range graph.Roots {
+			report.Dependencies = append(report.Dependencies, buildTree(graph, root, 1, expanded))
+		}
+
+		render(report, func() {
+			fmt.Println(colorize(ansiBlue, report.Name))
+			printTree(report.Dependencies, "")
+		})
+		return nil
+	},
+}
+
+func buildTree(graph *core.Graph, mv module.Version, depth int, expanded map[module.Version]bool) TreeNode {
+	ref := moduleRef(graph, mv)
+	node := TreeNode{Module: ref.Module, Version: ref.Version, Path: ref.Path, Selected: graph.Selected[mv.Path]}
+	if node.Selected == node.Version {
+		node.Selected = ""
+	}
+
+	reqs := graph.Edges[mv]
+	if len(reqs) == 0 {
+		return node
+	}
+	if expanded[mv] {
+		node.Deduped = true
+		return node
+	}
+	if treeDepth > 0 && depth >= treeDepth {
+		node.Truncated = true
+		return node
+	}
+
+	expanded[mv] = true
+	for _, r := range reqs {
+		node.Children = append(node.Children, buildTree(graph, r, depth+1, expanded))
+	}
+	return node
+}
+
+func printTree(nodes []TreeNode, prefix string) {
+	for i, n := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		line := ModuleRef{Module: n.Module, Version: n.Version, Path: n.Path}.String()
+		if n.Selected != "" {
+			line += colorize(ansiYellow, " → "+n.Selected)
+		}
+		if n.Deduped {
+			line += colorize(ansiGray, " (*)")
+		}
+		if n.Truncated {
+			line += colorize(ansiGray, " …")
+		}
+		fmt.Println(prefix + branch + line)
+		printTree(n.Children, prefix+next)
+	}
+}
+
+func init() {
+	treeCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Use the global gopkg.toml")
+	treeCmd.Flags().IntVar(&treeDepth, "depth", 0, "Limit the tree to this many levels (0 = unlimited)")
+	treeCmd.Flags().BoolVar(&treeOffline, "offline", false, "Only use installed and cached go.mod files")
+	rootCmd.AddCommand(treeCmd)
+}
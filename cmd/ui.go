@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pageton/gopkg/core"
+)
+
+const (
+	ansiRed    = "31"
+	ansiGreen  = "32"
+	ansiYellow = "33"
+	ansiBlue   = "34"
+	ansiCyan   = "36"
+	ansiGray   = "90"
+)
+
+var (
+	quietFlag    bool
+	verboseFlag  bool
+	colorEnabled           = detectColor()
+	logOutput    io.Writer = os.Stdout
+)
+
+func setupUI(cmd *cobra.Command, args []string) error {
+	if err := validateOutput(); err != nil {
+		return err
+	}
+	if quietFlag && verboseFlag {
+		return usageErrorf("--quiet and --verbose are mutually exclusive")
+	}
+
+	tty := isTerminal(os.Stdout)
+	switch cliConfig.Color() {
+	case "always":
+		colorEnabled = true
+	case "never":
+		colorEnabled = false
+	default:
+		colorEnabled = detectColor()
+	}
+
+	var reporter core.Reporter
+	switch {
+	case structuredOutput():
+		logOutput = os.Stderr
+		reporter = &jsonReporter{enc: json.NewEncoder(os.Stderr)}
+	case tty:
+		reporter = &ttyReporter{w: os.Stdout}
+	default:
+		reporter = &plainReporter{w: os.Stdout}
+	}
+	cmd.SetContext(core.WithReporter(cmd.Context(), filterReporter(reporter)))
+	return nil
+}
+
+func detectColor() bool {
+	return isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func colorize(code, s string) string {
+	if !colorEnabled || code == "" {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
+}
+
+// logf prints progress chatter that must stay out of structured output.
+func logf(format string, args ...any) {
+	if quietFlag {
+		return
+	}
+	fmt.Fprintf(logOutput, format, args...)
+}
+
+func successf(format string, args ...any) {
+	logf("%s\n", colorize(ansiGreen, "✔️ "+fmt.Sprintf(format, args...)))
+}
+
+func infof(format string, args ...any) {
+	logf("%s\n", colorize(ansiBlue, "ℹ️  "+fmt.Sprintf(format, args...)))
+}
+
+func warnf(format string, args ...any) {
+	fmt.Fprintf(logOutput, "%s\n", colorize(ansiYellow, "⚠️  "+fmt.Sprintf(format, args...)))
+}
+
+func verboseEvent(kind core.EventKind) bool {
+	switch kind {
+	case core.EventResolveStart, core.EventResolveDone, core.EventCacheHit,
+		core.EventExtractStart, core.EventExtractDone:
+		return true
+	}
+	return false
+}
+
+func filterReporter(r core.Reporter) core.Reporter {
+	return core.ReporterFunc(func(e core.Event) {
+		if e.Kind != core.EventWarning {
+			if quietFlag || (verboseEvent(e.Kind) && !verboseFlag) {
+				return
+			}
+		}
+		r.Report(e)
+	})
+}
+
+func eventTarget(e core.Event) string {
+	if e.Version == "" {
+		return e.Module
+	}
+	return e.Module + "@" + e.Version
+}
+
+type ttyReporter struct {
+	mu         sync.Mutex
+	w          io.Writer
+	inProgress bool
+}
+
+func (r *ttyReporter) Report(e core.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.Kind == core.EventDownloadProgress {
+		line := fmt.Sprintf("Progress: %s", formatBytes(e.Bytes))
+		if e.Total > 0 {
+			line = fmt.Sprintf("Progress: %.1f%%", float64(e.Bytes)/float64(e.Total)*100)
+		}
+		fmt.Fprintf(r.w, "\r%s", colorize(ansiCyan, line))
+		r.inProgress = true
+		return
+	}
+	if r.inProgress {
+		fmt.Fprint(r.w, "\r\033[K")
+		r.inProgress = false
+	}
+
+	switch e.Kind {
+	case core.EventInstallStart:
+		if e.Path != "" {
+			fmt.Fprintf(r.w, "[%d/%d] Linking %s -> %s...\n", e.Index, e.Count, e.Module, e.Path)
+		} else {
+			fmt.Fprintf(r.w, "[%d/%d] Installing %s...\n", e.Index, e.Count, eventTarget(e))
+		}
+	case core.EventResolveStart:
+		fmt.Fprintln(r.w, colorize(ansiGray, "🔎 Resolving "+eventTarget(e)+"..."))
+	case core.EventResolveDone:
+		fmt.Fprintln(r.w, colorize(ansiGray, "🔎 Resolved "+eventTarget(e)))
+	case core.EventCacheHit:
+		fmt.Fprintln(r.w, colorize(ansiCyan, "📦 Using cached "+eventTarget(e)))
+	case core.EventDownloadStart:
+		if e.Bytes > 0 {
+			fmt.Fprintln(r.w, colorize(ansiBlue, fmt.Sprintf("⬇️ Resuming %s at %s...", eventTarget(e), formatBytes(e.Bytes))))
+		} else {
+			fmt.Fprintln(r.w, colorize(ansiBlue, "⬇️ Downloading "+eventTarget(e)+"..."))
+		}
+	case core.EventDownloadDone:
+		fmt.Fprintln(r.w, colorize(ansiGreen, "✔️ Downloaded and cached "+eventTarget(e)))
+	case core.EventExtractStart:
+		fmt.Fprintln(r.w, colorize(ansiBlue, "ℹ️ Extracting to "+e.Path+"..."))
+	case core.EventExtractDone:
+		fmt.Fprintln(r.w, colorize(ansiGreen, fmt.Sprintf("✔️ Extracted %d files to %s", e.Files, e.Path)))
+	case core.EventWarning:
+		fmt.Fprintln(r.w, colorize(ansiYellow, "⚠️  "+strings.TrimSpace(eventTarget(e)+" "+e.Message)))
+	}
+}
+
+type plainReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (r *plainReporter) Report(e core.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Kind {
+	case core.EventDownloadProgress:
+		return
+	case core.EventInstallStart:
+		if e.Path != "" {
+			fmt.Fprintf(r.w, "[%d/%d] linking %s -> %s\n", e.Index, e.Count, e.Module, e.Path)
+		} else {
+			fmt.Fprintf(r.w, "[%d/%d] installing %s\n", e.Index, e.Count, eventTarget(e))
+		}
+	case core.EventResolveStart:
+		fmt.Fprintf(r.w, "resolving %s\n", eventTarget(e))
+	case core.EventResolveDone:
+		fmt.Fprintf(r.w, "resolved %s\n", eventTarget(e))
+	case core.EventCacheHit:
+		fmt.Fprintf(r.w, "using cached %s\n", eventTarget(e))
+	case core.EventDownloadStart:
+		if e.Bytes > 0 {
+			fmt.Fprintf(r.w, "resuming %s at %d bytes\n", eventTarget(e), e.Bytes)
+		} else {
+			fmt.Fprintf(r.w, "downloading %s\n", eventTarget(e))
+		}
+	case core.EventDownloadDone:
+		fmt.Fprintf(r.w, "downloaded %s\n", eventTarget(e))
+	case core.EventExtractStart:
+		fmt.Fprintf(r.w, "extracting to %s\n", e.Path)
+	case core.EventExtractDone:
+		fmt.Fprintf(r.w, "extracted %d files to %s\n", e.Files, e.Path)
+	case core.EventWarning:
+		fmt.Fprintf(r.w, "warning: %s\n", strings.TrimSpace(eventTarget(e)+" "+e.Message))
+	}
+}
+
+type jsonReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (r *jsonReporter) Report(e core.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(e)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -3,10 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
-	"sort"
 	"strings"
-	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -21,117 +18,160 @@ var updateCmd = &cobra.Command{
 		gopkg update
 		gopkg update -g
 	  gopkg update github.com/golang-jwt/jwt/v5@latest
+	  gopkg update --patch
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
-		tomlPath := core.GetTomlPath(globalFlag)
-		cfg, err := core.LoadToml(tomlPath)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if updateInteractive {
+			if len(args) > 0 {
+				return usageErrorf("--interactive does not take module arguments")
+			}
+			return runInteractiveUpdate(cmd)
+		}
+
+		limit, err := updateLimit()
 		if err != nil {
-			fmt.Printf("\033[31m✖️ Failed to load %s: %v\033[0m\n", tomlPath, err)
-			return
+			return err
 		}
 
-		locks, _ := core.LoadLockFile(globalFlag)
-		lockMap := map[string]core.LockEntry{}
-		for _, e := range locks {
-			lockMap[e.Name] = e
+		targets := map[string]string{}
+		for _, arg := range args {
+			if name, version, ok := strings.Cut(arg, "@"); ok {
+				targets[name] = version
+			} else {
+				targets[arg] = "latest"
+			}
 		}
 
-		allModules := make([]string, 0, len(cfg.Dependencies))
-		for m := range cfg.Dependencies {
-			allModules = append(allModules, m)
+		logf("\n🔍 Checking for updates...\n")
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
 		}
-		sort.Strings(allModules)
-
-		explicitUpdates := map[string]string{}
-		if len(args) > 0 {
-			for _, arg := range args {
-				if strings.Contains(arg, "@") {
-					parts := strings.SplitN(arg, "@", 2)
-					name, version := parts[0], parts[1]
-					explicitUpdates[name] = version
-				} else {
-					explicitUpdates[arg] = "latest"
-				}
-			}
-		} else {
-			for _, m := range allModules {
-				explicitUpdates[m] = "latest"
-			}
+		results, err := project.Update(cmd.Context(), core.UpdateOptions{Targets: targets, Limit: limit})
+		return renderUpdateResults(results, err)
+	},
+}
+
+// renderUpdateResults prints one row per dependency after an update run and
+// turns per-module failures into the command's error.
+func renderUpdateResults(results []core.ModuleResult, err error) error {
+	report := ModulesReport{Modules: []ModuleReport{}}
+	updated := 0
+	var errs []error
+	for _, r := range results {
+		report.Modules = append(report.Modules, moduleReport(r))
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+		if r.Status == StatusUpdated || r.Status == StatusFailed {
+			updated++
 		}
+	}
+	render(report, func() {
+		if len(report.Modules) > 0 {
+			renderUpdateTable(report)
+		}
+		if err == nil && updated == 0 && len(errs) == 0 {
+			successf("All selected dependencies are up to date.")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return failures(errs, "%d modules failed to update", len(errs))
+}
 
-		fmt.Println("\n🔍 Checking for updates...")
-		updated := false
-		results := [][]string{}
-		toInstall := []string{}
+func renderUpdateTable(report ModulesReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Module", "Declared", "Latest", "Status"})
+	table.SetRowLine(true)
+	table.SetAutoWrapText(false)
+	for _, row := range report.Modules {
+		table.Append([]string{row.Module, row.Declared, orDash(row.Latest), statusLabel(row.Status)})
+	}
+	table.Render()
+}
 
-		for _, mod := range allModules {
-			current := cfg.Dependencies[mod]
-			ver := explicitUpdates[mod]
-			if ver == "" {
-				continue
-			}
+// runInteractiveUpdate lets the user pick updates from the outdated report
+// and installs all of them in one pass.
+func runInteractiveUpdate(cmd *cobra.Command) error {
+	if structuredOutput() || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return usageErrorf("--interactive needs a terminal and table output")
+	}
 
-			var target string
-			if ver == "latest" {
-				latest, err := core.ResolveLatestVersion(mod)
-				if err != nil {
-					results = append(results, []string{mod, current, "—", "\033[31m✖️ Failed\033[0m"})
-					continue
-				}
-				target = latest
-			} else {
-				target = ver
-			}
+	limit, err := updateLimit()
+	if err != nil {
+		return err
+	}
+	project, err := openProject(globalFlag)
+	if err != nil {
+		return err
+	}
+	cfg, err := project.LoadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", project.Layout.TomlPath(), err)
+	}
 
-			cmp := core.CompareVersions(current, target)
-			status := "\033[32mUp-to-date\033[0m"
-			if cmp < 0 || ver != "latest" {
-				status = "\033[33mUpdate available\033[0m"
-				cfg.Dependencies[mod] = target
-				toInstall = append(toInstall, mod+"@"+target)
-				updated = true
-			}
-			results = append(results, []string{mod, current, target, status})
+	logf("\n🔍 Checking for updates...\n")
+	outdated, err := project.Outdated(cmd.Context())
+	if err != nil {
+		return err
+	}
+	for _, r := range outdated {
+		if r.Err != nil {
+			warnf("%v", r.Err)
 		}
+	}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Module", "Declared", "Latest", "Status"})
-		table.SetRowLine(true)
-		table.SetAutoWrapText(false)
-		table.AppendBulk(results)
-		table.Render()
-
-		if updated {
-			if err := core.SaveToml(tomlPath, cfg); err != nil {
-				fmt.Printf("\033[31m✖️ Failed to save updated gopkg.toml: %v\033[0m\n", err)
-				return
-			}
+	items := upgradeItems(outdated, func(module, current, version string) bool {
+		return cfg.Policy.Allows(module, current, version, limit)
+	})
+	if len(items) == 0 {
+		successf("All dependencies are up to date.")
+		return nil
+	}
 
-			fmt.Println("\n📦 Installing updated modules...")
-			for i, mod := range toInstall {
-				fmt.Printf("[%d/%d] Installing %s... ", i+1, len(toInstall), mod)
-				c := exec.Command(os.Args[0], "install", mod)
-				if globalFlag {
-					c.Args = append(c.Args, "--global", "-g")
-				}
-				c.Stdout = nil
-				c.Stderr = nil
-				err := c.Run()
-				if err != nil {
-					fmt.Printf("\033[31mFailed\033[0m\n")
-				} else {
-					fmt.Printf("\033[32mDone\033[0m\n")
-				}
-				time.Sleep(200 * time.Millisecond)
-			}
-			fmt.Println("\n✔️ Done.")
-		} else {
-			fmt.Println("\033[32m✔️ All selected dependencies are up to date.\033[0m")
-		}
-	},
+	chosen, err := runUpgradeSelector(items)
+	if err != nil {
+		return err
+	}
+	if len(chosen) == 0 {
+		infof("No updates selected.")
+		return nil
+	}
+
+	targets := map[string]string{}
+	for _, it := range chosen {
+		targets[it.module] = it.target().version
+	}
+
+	logf("\n📦 Installing %d selected updates...\n", len(chosen))
+	results, err := project.Update(cmd.Context(), core.UpdateOptions{Targets: targets})
+	return renderUpdateResults(results, err)
+}
+
+var (
+	updateInteractive bool
+	updatePatch       bool
+	updateMinor       bool
+)
+
+func updateLimit() (core.UpdatePolicy, error) {
+	switch {
+	case updatePatch && updateMinor:
+		return "", usageErrorf("--patch and --minor are mutually exclusive")
+	case updatePatch:
+		return core.PolicyPatch, nil
+	case updateMinor:
+		return core.PolicyMinor, nil
+	}
+	return "", nil
 }
 
 func init() {
 	updateCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Update global dependencies")
+	updateCmd.Flags().BoolVarP(&updateInteractive, "interactive", "i", false, "Pick updates from a checklist grouped by patch, minor and major")
+	updateCmd.Flags().BoolVar(&updatePatch, "patch", false, "Only apply patch updates")
+	updateCmd.Flags().BoolVar(&updateMinor, "minor", false, "Only apply patch and minor updates")
 	rootCmd.AddCommand(updateCmd)
 }
@@ -1,9 +1,7 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
@@ -18,59 +16,66 @@ var versionsCmd = &cobra.Command{
 	Example: `
 		gopkg versions github.com/golang-jwt/jwt/v5
 	`,
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		module := args[0]
-		url := fmt.Sprintf("https://proxy.golang.org/%s/@v/list", module)
 
-		resp, err := http.Get(url)
-		if err != nil || resp.StatusCode != 200 {
-			fmt.Printf("\033[31m✖️ Failed to fetch versions for %s\033[0m\n", module)
-			return
+		project, err := openProject(false)
+		if err != nil {
+			return err
 		}
-		defer resp.Body.Close()
-
-		var versions []string
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			versions = append(versions, scanner.Text())
-		}
-
-		if err := scanner.Err(); err != nil {
-			fmt.Println("\033[31m✖️ Error reading versions:\033[0m", err)
-			return
+		versions, err := project.Proxy.Versions(cmd.Context(), module)
+		if err != nil {
+			return err
 		}
 
 		sort.Sort(sort.Reverse(sort.StringSlice(versions)))
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"VERSION", "NOTE"})
-		table.SetBorder(true)
-		table.SetHeaderLine(true)
-		table.SetColumnSeparator("|")
-		table.SetCenterSeparator("+")
-		table.SetRowSeparator("-")
-		table.SetAlignment(tablewriter.ALIGN_CENTER)
-		table.SetAutoWrapText(false)
-		table.SetAutoFormatHeaders(false)
-
+		report := VersionsReport{Module: module, Versions: []VersionInfo{}}
 		for i, v := range versions {
-			note := ""
+			note := "older"
 			if i == 0 {
-				note = "\033[32mLatest\033[0m"
+				note = "latest"
 			} else if strings.Contains(v, "rc") || strings.Contains(v, "beta") || strings.Contains(v, "alpha") {
-				note = "\033[33mPre-release\033[0m"
-			} else {
-				note = "\033[90mOlder\033[0m"
+				note = "pre-release"
 			}
-			table.Append([]string{v, note})
+			report.Versions = append(report.Versions, VersionInfo{Version: v, Note: note})
 		}
 
-		fmt.Printf("\n\033[34mAvailable versions for %s:\033[0m\n\n", module)
-		table.Render()
+		render(report, func() {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"VERSION", "NOTE"})
+			table.SetBorder(true)
+			table.SetHeaderLine(true)
+			table.SetColumnSeparator("|")
+			table.SetCenterSeparator("+")
+			table.SetRowSeparator("-")
+			table.SetAlignment(tablewriter.ALIGN_CENTER)
+			table.SetAutoWrapText(false)
+			table.SetAutoFormatHeaders(false)
+
+			for _, v := range report.Versions {
+				table.Append([]string{v.Version, versionNoteLabel(v.Note)})
+			}
+
+			fmt.Printf("\n%s\n\n", colorize(ansiBlue, "Available versions for "+module+":"))
+			table.Render()
+		})
+		return nil
 	},
 }
 
+func versionNoteLabel(note string) string {
+	switch note {
+	case "latest":
+		return colorize(ansiGreen, "Latest")
+	case "pre-release":
+		return colorize(ansiYellow, "Pre-release")
+	default:
+		return colorize(ansiGray, "Older")
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(versionsCmd)
 }
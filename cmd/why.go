@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/module"
+
+	"github.com/pageton/gopkg/core"
+)
+
+var (
+	whyOffline bool
+	whyLimit   int
+	whyAll     bool
+)
+
+var whyCmd = &cobra.Command{
+	Use:   "why <module>",
+	Short: "Show why a module is part of the build",
+	Example: `
+  gopkg why golang.org/x/sys
+  gopkg why --offline golang.org/x/text
+  gopkg why --all golang.org/x/sys
+`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := args[0]
+		project, err := openProject(globalFlag)
+		if err != nil {
+			return err
+		}
+		graph, err := project.LoadGraph(cmd.Context(), core.GraphOptions{Offline: whyOffline})
+		if err != nil {
+			return err
+		}
+		warnMissing(graph)
+
+		selected, ok := graph.Selected[target]
+		if !ok {
+			return &core.ModuleError{Module: target, Kind: core.ErrNotFound, Err: fmt.Errorf("not in the dependency graph")}
+		}
+
+		report := WhyReport{Module: target, Selected: selected, SelectedBy: []ModuleRef{}, Paths: []WhyPath{}}
+		if graph.IsRoot(target) {
+			report.SelectedBy = append(report.SelectedBy, ModuleRef{Module: "gopkg.toml"})
+		} else {
+			for from, reqs := range graph.Edges {
+				for _, r := range reqs {
+					if r.Path == target && r.Version == selected {
+						report.SelectedBy = append(report.SelectedBy, moduleRef(graph, from))
+					}
+				}
+			}
+			sortRefs(report.SelectedBy)
+		}
+
+		var paths [][]module.Version
+		if whyAll || whyLimit <= 0 {
+			paths = graph.Paths(target, 0)
+		} else {
+			// One path more than shown tells whether there are others.
+			paths = graph.Paths(target, whyLimit+1)
+			if len(paths) > whyLimit {
+				paths, report.Truncated = paths[:whyLimit], true
+			}
+		}
+		for _, path := range paths {
+			wp := WhyPath{}
+			for _, mv := range path {
+				wp.Path = append(wp.Path, moduleRef(graph, mv))
+			}
+			last := path[len(path)-1]
+			wp.Requires = last.Version
+			wp.Selected = last.Version == selected
+			report.Paths = append(report.Paths, wp)
+		}
+
+		render(report, func() { renderWhy(report) })
+		return nil
+	},
+}
+
+func renderWhy(report WhyReport) {
+	fmt.Printf("\n%s\n", colorize(ansiBlue, fmt.Sprintf("🔍 %s is selected at %s", report.Module, orDash(report.Selected))))
+	by := make([]string, 0, len(report.SelectedBy))
+	for _, ref := range report.SelectedBy {
+		by = append(by, ref.String())
+	}
+	fmt.Printf("   chosen by: %s\n\n", strings.Join(by, ", "))
+
+	for i, p := range report.Paths {
+		steps := []string{"gopkg.toml"}
+		for _, ref := range p.Path {
+			steps = append(steps, ref.String())
+		}
+		line := fmt.Sprintf("%d. %s", i+1, strings.Join(steps, " → "))
+		if p.Selected {
+			line += " " + colorize(ansiGreen, "✔ selected")
+		} else {
+			line += " " + colorize(ansiGray, "(superseded)")
+		}
+		fmt.Println(line)
+	}
+	if report.Truncated {
+		fmt.Println(colorize(ansiGray, fmt.Sprintf("… more paths not shown, use --limit or --all to see beyond the shortest %d", len(report.Paths))))
+	}
+}
+
+func moduleRef(graph *core.Graph, mv module.Version) ModuleRef {
+	ref := ModuleRef{Module: mv.Path, Version: mv.Version}
+	if link, ok := graph.Linked[mv.Path]; ok && graph.IsRoot(mv.Path) {
+		ref.Path = link
+	}
+	return ref
+}
+
+func warnMissing(graph *core.Graph) {
+	if n := len(graph.Missing); n > 0 {
+		names := make([]string, 0, n)
+		for _, mv := range graph.Missing {
+			names = append(names, mv.String())
+		}
+		warnf("go.mod could not be loaded for %d module(s), the graph below them is incomplete: %s", n, strings.Join(names, ", "))
+	}
+}
+
+func init() {
+	whyCmd.Flags().BoolVarP(&globalFlag, "global", "g", false, "Use the global gopkg.toml")
+	whyCmd.Flags().BoolVar(&whyOffline, "offline", false, "Only use installed and cached go.mod files")
+	whyCmd.Flags().IntVar(&whyLimit, "limit", 10, "Show at most this many paths, shortest first (0 = all)")
+	whyCmd.Flags().BoolVar(&whyAll, "all", false, "Show every path, however many there are")
+	rootCmd.AddCommand(whyCmd)
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Vulnerability is a database entry that affects a locked module version.
+type Vulnerability struct {
+	ID       string   `json:"id" yaml:"id"`
+	Aliases  []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Module   string   `json:"module" yaml:"module"`
+	Version  string   `json:"version" yaml:"version"`
+	Indirect bool     `json:"indirect,omitempty" yaml:"indirect,omitempty"`
+	// Severity is low, medium, high or critical when the entry rates it.
+	Severity string  `json:"severity" yaml:"severity"`
+	Score    float64 `json:"score,omitempty" yaml:"score,omitempty"`
+	CVSS     string  `json:"cvss,omitempty" yaml:"cvss,omitempty"`
+	Summary  string  `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// Affected is the vulnerable ranges, e.g. ">=v1.2.0, <v1.2.5".
+	Affected string `json:"affected" yaml:"affected"`
+	// Fixed is the lowest version above Version without the vulnerability,
+	// empty when there is no fix yet.
+	Fixed string `json:"fixed,omitempty" yaml:"fixed,omitempty"`
+	URL   string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Symbols are the vulnerable functions, e.g. "example.com/x/y.Parse".
+	Symbols []string `json:"symbols,omitempty" yaml:"symbols,omitempty"`
+	// Reachable and Trace are set by Reachability.
+	Reachable Reach    `json:"reachable,omitempty" yaml:"reachable,omitempty"`
+	Trace     []string `json:"trace,omitempty" yaml:"trace,omitempty"`
+
+	imports []OSVImport
+}
+
+// Audit checks every locked module version, direct and indirect, against db.
+func (p *Project) Audit(ctx context.Context, db *VulnDB) ([]Vulnerability, error) {
+	if _, err := os.Stat(p.Layout.LockPath()); err != nil {
+		return nil, NewError(ErrNotFound, "%s not found, run `gopkg install` first", p.Layout.LockPath())
+	}
+	entries, err := p.LoadLock()
+	if err != nil {
+		return nil, err
+	}
+	index, err := db.Modules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vulns := []Vulnerability{}
+	for _, e := range entries {
+		if !semver.IsValid(e.Resolved) {
+			continue
+		}
+		for _, id := range index[e.Name] {
+			if ctx.Err() != nil {
+				return vulns, ctx.Err()
+			}
+			osv, err := db.Entry(ctx, id)
+			if err != nil {
+				return vulns, err
+			}
+			hit, fixed := osv.affects(e.Name, e.Resolved)
+			if !hit {
+				continue
+			}
+			severity, score, vector := osv.severity()
+			v := Vulnerability{
+				ID:       osv.ID,
+				Aliases:  osv.Aliases,
+				Module:   e.Name,
+				Version:  e.Resolved,
+				Indirect: e.Indirect,
+				Severity: severity,
+				Score:    score,
+				CVSS:     vector,
+				Summary:  osv.Summary,
+				Affected: osv.describe(e.Name),
+				Fixed:    fixed,
+				URL:      osv.DatabaseSpecific.URL,
+			}
+			v.imports = osv.imports(e.Name)
+			for _, imp := range v.imports {
+				for _, sym := range imp.Symbols {
+					v.Symbols = append(v.Symbols, imp.Path+"."+sym)
+				}
+			}
+			if v.URL == "" && strings.HasPrefix(v.ID, "GO-") {
+				v.URL = "https://pkg.go.dev/vuln/" + v.ID
+			}
+			vulns = append(vulns, v)
+		}
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].Module != vulns[j].Module {
+			return vulns[i].Module < vulns[j].Module
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+	return vulns, nil
+}
+
+// AuditFix is the upgrade that clears a module's vulnerabilities, or why
+// there is none: To is empty and Skipped says why.
+type AuditFix struct {
+	Module  string   `json:"module" yaml:"module"`
+	From    string   `json:"from" yaml:"from"`
+	To      string   `json:"to,omitempty" yaml:"to,omitempty"`
+	Vulns   []string `json:"vulns" yaml:"vulns"`
+	Skipped string   `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// PlanFixes picks, for every vulnerable direct dependency, the lowest release
+// at or above all the fixed versions that the module's update policy and
+// ignore list allow.
+func (p *Project) PlanFixes(ctx context.Context, vulns []Vulnerability) ([]AuditFix, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+
+	byModule := map[string][]Vulnerability{}
+	for _, v := range vulns {
+		byModule[v.Module] = append(byModule[v.Module], v)
+	}
+
+	fixes := []AuditFix{}
+	for _, module := range sortedKeys(byModule) {
+		fix := AuditFix{Module: module, From: byModule[module][0].Version}
+		need := ""
+		for _, v := range byModule[module] {
+			fix.Vulns = append(fix.Vulns, v.ID)
+			if v.Fixed == "" {
+				fix.Skipped = "no fixed version for " + v.ID
+			}
+			need = maxVersion(need, v.Fixed)
+		}
+		if _, direct := cfg.Dependencies[module]; !direct {
+			fix.Skipped = "indirect dependency, update the module that requires it"
+		}
+		if fix.Skipped != "" {
+			fixes = append(fixes, fix)
+			continue
+		}
+
+		policy, ignore, err := cfg.Policy.Rule(module, "")
+		if err != nil {
+			return fixes, err
+		}
+		if policy == PolicyNone {
+			fix.Skipped = "pinned by update policy"
+			fixes = append(fixes, fix)
+			continue
+		}
+		versions, err := p.releases(ctx, module)
+		if err != nil {
+			return fixes, err
+		}
+		semver.Sort(versions)
+		for _, v := range versions {
+			if semver.Prerelease(v) == "" && semver.Compare(v, need) >= 0 && allowedUpdate(fix.From, v, policy, ignore) {
+				fix.To = v
+				break
+			}
+		}
+		if fix.To == "" {
+			fix.Skipped = fmt.Sprintf("%s is not allowed by the %s update policy", need, policy)
+		}
+		fixes = append(fixes, fix)
+	}
+	return fixes, nil
+}
+
+// ApplyFixes updates gopkg.toml to the planned versions and installs them.
+func (p *Project) ApplyFixes(ctx context.Context, fixes []AuditFix) ([]ModuleResult, error) {
+	targets := map[string]string{}
+	for _, f := range fixes {
+		if f.To != "" {
+			targets[f.Module] = f.To
+		}
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	return p.Update(ctx, UpdateOptions{Targets: targets, command: "audit --fix"})
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOSVRangeContains(t *testing.T) {
+	twoRanges := OSVRange{Type: "SEMVER", Events: []OSVEvent{
+		{Introduced: "0.2.0"}, {Fixed: "0.2.1"}, {Introduced: "0.3.0"}, {Fixed: "1.0.0"},
+	}}
+	fromZero := OSVRange{Type: "SEMVER", Events: []OSVEvent{{Introduced: "0"}, {Fixed: "1.0.1"}}}
+	unfixed := OSVRange{Type: "SEMVER", Events: []OSVEvent{{Introduced: "1.0.0"}}}
+	unsorted := OSVRange{Type: "SEMVER", Events: []OSVEvent{{Fixed: "v1.4.0"}, {Introduced: "v1.2.0"}}}
+
+	tests := []struct {
+		name    string
+		r       OSVRange
+		version string
+		in      bool
+		fixed   string
+	}{
+		{"before the first range", twoRanges, "v0.1.0", false, ""},
+		{"introduced", twoRanges, "v0.2.0", true, "v0.2.1"},
+		{"fixed", twoRanges, "v0.2.1", false, ""},
+		{"between ranges", twoRanges, "v0.2.5", false, ""},
+		{"second range", twoRanges, "v0.3.0", true, "v1.0.0"},
+		{"inside the second range", twoRanges, "v0.9.9", true, "v1.0.0"},
+		{"second fix", twoRanges, "v1.0.0", false, ""},
+		{"after every range", twoRanges, "v2.0.0", false, ""},
+		{"introduced at 0", fromZero, "v0.0.1", true, "v1.0.1"},
+		{"just before the fix", fromZero, "v1.0.0", true, "v1.0.1"},
+		{"at the fix", fromZero, "v1.0.1", false, ""},
+		{"before an unfixed range", unfixed, "v0.9.0", false, ""},
+		{"unfixed", unfixed, "v1.0.0", true, ""},
+		{"long after an unfixed range", unfixed, "v5.0.0", true, ""},
+		{"prerelease of the introduced version", unfixed, "v1.0.0-rc.1", false, ""},
+		{"events out of order", unsorted, "v1.3.0", true, "v1.4.0"},
+		{"events out of order, fixed", unsorted, "v1.4.0", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, fixed := tt.r.contains(tt.version)
+			if in != tt.in || fixed != tt.fixed {
+				t.Errorf("contains(%s) = %v, %q, want %v, %q", tt.version, in, fixed, tt.in, tt.fixed)
+			}
+		})
+	}
+}
+
+func TestOSVEntryAffects(t *testing.T) {
+	affected := func(module string, events ...OSVEvent) OSVAffected {
+		var a OSVAffected
+		a.Package.Name = module
+		if len(events) > 0 {
+			a.Ranges = []OSVRange{{Type: "SEMVER", Events: events}}
+		}
+		return a
+	}
+	entry := &OSVEntry{ID: "GO-2099-0100", Affected: []OSVAffected{
+		affected("example.com/a", OSVEvent{Introduced: "0"}, OSVEvent{Fixed: "1.2.0"}),
+		affected("example.com/a", OSVEvent{Introduced: "1.0.0"}, OSVEvent{Fixed: "1.1.0"}),
+		affected("example.com/all"),
+		{Package: affected("example.com/git").Package, Ranges: []OSVRange{{Type: "GIT", Events: []OSVEvent{{Introduced: "0"}}}}},
+	}}
+
+	tests := []struct {
+		module, version string
+		hit             bool
+		fixed           string
+	}{
+		{"example.com/a", "v0.5.0", true, "v1.2.0"},
+		{"example.com/a", "v1.0.5", true, "v1.1.0"},
+		{"example.com/a", "v1.1.0", true, "v1.2.0"},
+		{"example.com/a", "v1.2.0", false, ""},
+		{"example.com/all", "v3.0.0", true, ""},
+		{"example.com/git", "v1.0.0", false, ""},
+		{"example.com/other", "v0.5.0", false, ""},
+	}
+	for _, tt := range tests {
+		hit, fixed := entry.affects(tt.module, tt.version)
+		if hit != tt.hit || fixed != tt.fixed {
+			t.Errorf("affects(%s, %s) = %v, %q, want %v, %q", tt.module, tt.version, hit, fixed, tt.hit, tt.fixed)
+		}
+	}
+}
+
+// auditProject locks baz, qux and foo at vulnerable versions and bar at a
+// clean one, against the database in testdata/vulndb.
+func auditProject(t *testing.T, policy string) *Project {
+	t.Helper()
+	proxy := newTestProxy(t,
+		testModule{Path: "example.com/baz", Version: "v0.3.0"},
+		testModule{Path: "example.com/baz", Version: "v0.3.1"},
+		testModule{Path: "example.com/baz", Version: "v1.0.0"},
+		testModule{Path: "example.com/qux", Version: "v1.0.0"},
+		testModule{Path: "example.com/qux", Version: "v1.0.1"},
+		testModule{Path: "example.com/qux", Version: "v1.0.2"},
+		testModule{Path: "example.com/qux", Version: "v1.1.0"},
+		testModule{Path: "example.com/qux", Version: "v1.2.0-rc.1"},
+	)
+	p := newTestProject(t, proxy, `name = "app"
+
+[dependencies]
+"example.com/bar" = "v1.2.0"
+"example.com/baz" = "v0.3.0"
+"example.com/qux" = "v1.0.0"
+
+`+policy)
+	err := p.WriteLock([]LockEntry{
+		{Name: "example.com/bar", Version: "v1.2.0", Resolved: "v1.2.0"},
+		{Name: "example.com/baz", Version: "v0.3.0", Resolved: "v0.3.0"},
+		{Name: "example.com/foo", Resolved: "v1.0.0", Indirect: true},
+		{Name: "example.com/qux", Version: "v1.0.0", Resolved: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestAudit(t *testing.T) {
+	p := auditProject(t, "")
+	vulns, err := p.Audit(context.Background(), OpenVulnDB("testdata/vulndb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type finding struct {
+		ID, Module, Fixed, Severity string
+		Indirect                    bool
+		Symbols                     []string
+	}
+	var got []finding
+	for _, v := range vulns {
+		got = append(got, finding{v.ID, v.Module, v.Fixed, v.Severity, v.Indirect, v.Symbols})
+	}
+	want := []finding{
+		{"GO-2099-0001", "example.com/baz", "v1.0.0", "high", false, nil},
+		{"GO-2099-0003", "example.com/foo", "", "low", true, nil},
+		{"GO-2099-0002", "example.com/qux", "v1.0.1", "high", false, nil},
+		{"GO-2099-0004", "example.com/qux", "v1.0.2", "moderate", false, []string{"example.com/qux/client.Client.Do"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Audit =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestPlanFixes(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   map[string]string // module -> To, or "skip: <reason prefix>"
+	}{
+		{
+			name: "no policy",
+			want: map[string]string{
+				"example.com/baz": "v1.0.0",
+				"example.com/foo": "skip: indirect dependency",
+				"example.com/qux": "v1.0.2",
+			},
+		},
+		{
+			name:   "minor policy keeps baz on v0",
+			policy: "[policy]\nupdate = \"minor\"\n",
+			want: map[string]string{
+				"example.com/baz": "skip: v1.0.0 is not allowed by the minor update policy",
+				"example.com/foo": "skip: indirect dependency",
+				"example.com/qux": "v1.0.2",
+			},
+		},
+		{
+			name:   "ignored fix moves to the next release",
+			policy: "[policy.dependencies.\"example.com/qux\"]\nignore = [\"v1.0.2\"]\n",
+			want: map[string]string{
+				"example.com/baz": "v1.0.0",
+				"example.com/foo": "skip: indirect dependency",
+				"example.com/qux": "v1.1.0",
+			},
+		},
+		{
+			name:   "patch policy with the fix ignored",
+			policy: "[policy.dependencies.\"example.com/qux\"]\nupdate = \"patch\"\nignore = [\">=v1.0.2 <v1.1.0\"]\n",
+			want: map[string]string{
+				"example.com/baz": "v1.0.0",
+				"example.com/foo": "skip: indirect dependency",
+				"example.com/qux": "skip: v1.0.2 is not allowed by the patch update policy",
+			},
+		},
+		{
+			name:   "pinned",
+			policy: "[policy]\nupdate = \"none\"\n",
+			want: map[string]string{
+				"example.com/baz": "skip: pinned by update policy",
+				"example.com/foo": "skip: indirect dependency",
+				"example.com/qux": "skip: pinned by update policy",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := auditProject(t, tt.policy)
+			ctx := context.Background()
+			vulns, err := p.Audit(ctx, OpenVulnDB("testdata/vulndb"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fixes, err := p.PlanFixes(ctx, vulns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := p.LoadManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for _, f := range fixes {
+				got[f.Module] = f.To
+				if f.Skipped != "" {
+					got[f.Module] = "skip: " + f.Skipped
+				} else if !cfg.Policy.Allows(f.Module, f.From, f.To, "") {
+					t.Errorf("%s: %s -> %s is outside the policy", f.Module, f.From, f.To)
+				}
+			}
+			for module, want := range tt.want {
+				if !strings.HasPrefix(got[module], want) {
+					t.Errorf("%s: got %q, want %q", module, got[module], want)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("fixes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
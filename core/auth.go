@@ -0,0 +1,300 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+type HostAuth struct {
+	Token    string `toml:"token,omitempty"`
+	Username string `toml:"username,omitempty"`
+	Password string `toml:"password,omitempty"`
+}
+
+type UserConfig struct {
+	Settings Settings            `toml:"settings,omitempty"`
+	Auth     map[string]HostAuth `toml:"auth,omitempty"`
+}
+
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+var (
+	authMu    sync.Mutex
+	authCache = map[string]http.Header{}
+)
+
+func LoadUserConfig() (*UserConfig, error) {
+	return LoadUserConfigAt(GetConfigPath())
+}
+
+func LoadUserConfigAt(path string) (*UserConfig, error) {
+	cfg := &UserConfig{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (p *Proxy) authorize(req *http.Request) error {
+	if req.URL.User != nil {
+		return nil
+	}
+
+	key := p.ConfigPath + "|" + req.URL.Host
+	authMu.Lock()
+	headers, ok := authCache[key]
+	authMu.Unlock()
+
+	if !ok {
+		var err error
+		headers, err = lookupCredentials(p.ConfigPath, req.URL)
+		if err != nil {
+			return err
+		}
+		authMu.Lock()
+		authCache[key] = headers
+		authMu.Unlock()
+	}
+
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	return nil
+}
+
+func lookupCredentials(configPath string, u *url.URL) (http.Header, error) {
+	cfg, err := LoadUserConfigAt(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if auth, ok := cfg.Auth[u.Host]; ok {
+		return hostAuthHeader(auth), nil
+	}
+	if auth, ok := cfg.Auth[u.Hostname()]; ok {
+		return hostAuthHeader(auth), nil
+	}
+
+	goauth := os.Getenv("GOAUTH")
+	if goauth == "" {
+		goauth = "netrc"
+	}
+	for _, helper := range strings.Split(goauth, ";") {
+		helper = strings.TrimSpace(helper)
+		switch {
+		case helper == "":
+			continue
+		case helper == "off":
+			return http.Header{}, nil
+		case helper == "netrc":
+			if h := netrcHeader(u.Hostname()); h != nil {
+				return h, nil
+			}
+		case strings.HasPrefix(helper, "git "):
+			if h, err := gitCredentialHeader(strings.TrimSpace(strings.TrimPrefix(helper, "git ")), u); err != nil {
+				return nil, err
+			} else if h != nil {
+				return h, nil
+			}
+		default:
+			if h, err := commandCredentialHeader(helper, u); err != nil {
+				return nil, err
+			} else if h != nil {
+				return h, nil
+			}
+		}
+	}
+	return http.Header{}, nil
+}
+
+func hostAuthHeader(auth HostAuth) http.Header {
+	h := http.Header{}
+	if auth.Token != "" {
+		h.Set("Authorization", "Bearer "+auth.Token)
+	} else if auth.Username != "" || auth.Password != "" {
+		req := &http.Request{Header: h}
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	return h
+}
+
+func netrcPath() string {
+	if p := os.Getenv("NETRC"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+func netrcHeader(host string) http.Header {
+	path := netrcPath()
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var fallback *netrcEntry
+	for _, e := range parseNetrc(string(data)) {
+		if e.machine == host {
+			return hostAuthHeader(HostAuth{Username: e.login, Password: e.password})
+		}
+		if e.machine == "" && fallback == nil {
+			fallback = &e
+		}
+	}
+	if fallback != nil {
+		return hostAuthHeader(HostAuth{Username: fallback.login, Password: fallback.password})
+	}
+	return nil
+}
+
+func parseNetrc(data string) []netrcEntry {
+	var entries []netrcEntry
+	var cur *netrcEntry
+	inMacro := false
+
+	for _, line := range strings.Split(data, "\n") {
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "machine":
+				if cur != nil {
+					entries = append(entries, *cur)
+				}
+				cur = &netrcEntry{}
+				if i+1 < len(fields) {
+					cur.machine = fields[i+1]
+					i++
+				}
+			case "default":
+				if cur != nil {
+					entries = append(entries, *cur)
+				}
+				cur = &netrcEntry{}
+			case "login":
+				if cur != nil && i+1 < len(fields) {
+					cur.login = fields[i+1]
+					i++
+				}
+			case "password":
+				if cur != nil && i+1 < len(fields) {
+					cur.password = fields[i+1]
+					i++
+				}
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	if cur != nil {
+		entries = append(entries, *cur)
+	}
+	return entries
+}
+
+func gitCredentialHeader(dir string, u *url.URL) (http.Header, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\n\n", u.Scheme, u.Host))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git credential helper failed for %s", u.Host)
+	}
+
+	var auth HostAuth
+	for _, line := range strings.Split(string(out), "\n") {
+		if v, ok := strings.CutPrefix(line, "username="); ok {
+			auth.Username = v
+		} else if v, ok := strings.CutPrefix(line, "password="); ok {
+			auth.Password = v
+		}
+	}
+	if auth.Username == "" && auth.Password == "" {
+		return nil, nil
+	}
+	return hostAuthHeader(auth), nil
+}
+
+func commandCredentialHeader(command string, u *url.URL) (http.Header, error) {
+	args := strings.Fields(command)
+	target := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+	cmd := exec.Command(args[0], append(args[1:], target)...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %q failed for %s", args[0], u.Host)
+	}
+	return parseCredentialOutput(out, target)
+}
+
+// parseCredentialOutput reads the GOAUTH command format: one or more URL
+// prefixes, a blank line, MIME headers, and a blank line, repeated.
+func parseCredentialOutput(out []byte, target string) (http.Header, error) {
+	var best http.Header
+	bestLen := -1
+
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(out)))
+	for {
+		var prefixes []string
+		for {
+			line, err := r.ReadLine()
+			if err != nil {
+				return best, nil
+			}
+			if line == "" {
+				break
+			}
+			prefixes = append(prefixes, line)
+		}
+		if len(prefixes) == 0 {
+			continue
+		}
+
+		header, err := r.ReadMIMEHeader()
+		if err != nil && len(header) == 0 {
+			return nil, fmt.Errorf("malformed credential helper output")
+		}
+
+		for _, p := range prefixes {
+			if strings.HasPrefix(target, p) && len(p) > bestLen {
+				best, bestLen = http.Header(header), len(p)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []netrcEntry
+	}{
+		{
+			name: "one line per machine",
+			data: "machine proxy.example.com login alice password s3cret\nmachine git.example.com login bob password hunter2\n",
+			want: []netrcEntry{
+				{"proxy.example.com", "alice", "s3cret"},
+				{"git.example.com", "bob", "hunter2"},
+			},
+		},
+		{
+			name: "tokens across lines",
+			data: "machine proxy.example.com\n  login alice\n  password s3cret\n",
+			want: []netrcEntry{{"proxy.example.com", "alice", "s3cret"}},
+		},
+		{
+			name: "default entry",
+			data: "machine a.example.com login a password pa\ndefault login anon password guest\n",
+			want: []netrcEntry{{"a.example.com", "a", "pa"}, {"", "anon", "guest"}},
+		},
+		{
+			name: "macro bodies are skipped",
+			data: "machine a.example.com login a password pa\nmacdef init\nmachine fake login x password y\n\nmachine b.example.com login b password pb\n",
+			want: []netrcEntry{{"a.example.com", "a", "pa"}, {"b.example.com", "b", "pb"}},
+		},
+		{
+			name: "login before any machine is ignored",
+			data: "login stray password stray\nmachine a.example.com login a\n",
+			want: []netrcEntry{{"a.example.com", "a", ""}},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNetrc(tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNetrc = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetrcHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "netrc")
+	writeFile(t, path, "machine proxy.example.com login alice password s3cret\ndefault login anon password guest\n")
+	t.Setenv("NETRC", path)
+
+	basic := func(user, pass string) string {
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+		req.SetBasicAuth(user, pass)
+		return req.Header.Get("Authorization")
+	}
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"proxy.example.com", basic("alice", "s3cret")},
+		{"other.example.com", basic("anon", "guest")},
+	}
+	for _, tt := range tests {
+		if got := netrcHeader(tt.host).Get("Authorization"); got != tt.want {
+			t.Errorf("netrcHeader(%s) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "missing"))
+	if h := netrcHeader("proxy.example.com"); h != nil {
+		t.Errorf("netrcHeader without a netrc file = %v", h)
+	}
+}
@@ -0,0 +1,473 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const DefaultJobs = 4
+
+type ConfigSource string
+
+const (
+	SourceDefault ConfigSource = "default"
+	SourceUser    ConfigSource = "user"
+	SourceProject ConfigSource = "project"
+	SourceEnv     ConfigSource = "env"
+	SourceFlag    ConfigSource = "flag"
+)
+
+// Settings is the [settings] table shared by the user config.toml and a
+// project's gopkg.toml.
+type Settings struct {
+	Proxy       string `toml:"proxy,omitempty"`
+	Jobs        int    `toml:"jobs,omitzero"`
+	InstallMode string `toml:"install_mode,omitempty"`
+	// Replaces is the file install writes replace directives to: go.mod,
+	// or go.work to keep the committed go.mod free of them.
+	Replaces string `toml:"replaces,omitempty"`
+	VulnDB   string `toml:"vulndb,omitempty"`
+	Color    string `toml:"color,omitempty"`
+}
+
+type ConfigValue struct {
+	Key    string       `json:"key" yaml:"key"`
+	Value  string       `json:"value" yaml:"value"`
+	Source ConfigSource `json:"source" yaml:"source"`
+	Origin string       `json:"origin,omitempty" yaml:"origin,omitempty"`
+}
+
+// Config is the effective configuration: built-in defaults, then the user
+// config file, then the project's [settings], then environment variables,
+// then flags applied with Override.
+type Config struct {
+	Settings Settings
+	Auth     map[string]HostAuth
+	values   map[string]ConfigValue
+	authFrom string
+}
+
+type setting struct {
+	key   string
+	def   string
+	env   func() (value, name string)
+	check func(string) (string, error)
+	get   func(*Settings) string
+	set   func(*Settings, string)
+}
+
+var settings = []setting{
+	{
+		key:   "proxy",
+		def:   defaultProxy,
+		env:   envProxy,
+		check: checkProxy,
+		get:   func(s *Settings) string { return s.Proxy },
+		set:   func(s *Settings, v string) { s.Proxy = v },
+	},
+	{
+		key:   "jobs",
+		def:   strconv.Itoa(DefaultJobs),
+		env:   envVar("GOPKG_JOBS"),
+		check: checkJobs,
+		get: func(s *Settings) string {
+			if s.Jobs == 0 {
+				return ""
+			}
+			return strconv.Itoa(s.Jobs)
+		},
+		set: func(s *Settings, v string) { s.Jobs, _ = strconv.Atoi(v) },
+	},
+	{
+		key:   "install_mode",
+		def:   "local",
+		env:   envVar("GOPKG_INSTALL_MODE"),
+		check: oneOf("local", "global"),
+		get:   func(s *Settings) string { return s.InstallMode },
+		set:   func(s *Settings, v string) { s.InstallMode = v },
+	},
+	{
+		key:   "replaces",
+		def:   "go.mod",
+		env:   envVar("GOPKG_REPLACES"),
+		check: oneOf("go.mod", "go.work"),
+		get:   func(s *Settings) string { return s.Replaces },
+		set:   func(s *Settings, v string) { s.Replaces = v },
+	},
+	{
+		key:   "vulndb",
+		def:   defaultVulnDB,
+		env:   envVulnDB,
+		check: checkVulnDB,
+		get:   func(s *Settings) string { return s.VulnDB },
+		set:   func(s *Settings, v string) { s.VulnDB = v },
+	},
+	{
+		key:   "color",
+		def:   "auto",
+		env:   envColor,
+		check: oneOf("auto", "always", "never"),
+		get:   func(s *Settings) string { return s.Color },
+		set:   func(s *Settings, v string) { s.Color = v },
+	},
+}
+
+func SettingKeys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, s := range settings {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+func findSetting(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+func envVar(name string) func() (string, string) {
+	return func() (string, string) { return os.Getenv(name), name }
+}
+
+func envProxy() (string, string) {
+	if p := os.Getenv("GOPKG_PROXY"); p != "" {
+		return p, "GOPKG_PROXY"
+	}
+	for _, p := range strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' }) {
+		p = strings.TrimSpace(p)
+		if p == "" || p == "direct" || p == "off" {
+			continue
+		}
+		return p, "GOPROXY"
+	}
+	return "", ""
+}
+
+func envVulnDB() (string, string) {
+	for _, name := range []string{"GOPKG_VULNDB", "GOVULNDB"} {
+		if v := os.Getenv(name); v != "" {
+			return v, name
+		}
+	}
+	return "", ""
+}
+
+func envColor() (string, string) {
+	if c := os.Getenv("GOPKG_COLOR"); c != "" {
+		return c, "GOPKG_COLOR"
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return "never", "NO_COLOR"
+	}
+	return "", ""
+}
+
+func checkProxy(v string) (string, error) {
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("proxy must be an http(s) URL, got %q", RedactURL(v))
+	}
+	return strings.TrimSuffix(v, "/"), nil
+}
+
+// checkVulnDB accepts a server URL or a local directory.
+func checkVulnDB(v string) (string, error) {
+	if v == "" {
+		return "", fmt.Errorf("vulndb must be a URL or a directory")
+	}
+	if u, err := url.Parse(v); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return strings.TrimSuffix(v, "/"), nil
+	}
+	return v, nil
+}
+
+func checkJobs(v string) (string, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("jobs must be a positive number, got %q", v)
+	}
+	return strconv.Itoa(n), nil
+}
+
+func oneOf(allowed ...string) func(string) (string, error) {
+	return func(v string) (string, error) {
+		for _, a := range allowed {
+			if v == a {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("must be one of %s, got %q", strings.Join(allowed, ", "), v)
+	}
+}
+
+func LoadConfig(l Layout) (*Config, error) {
+	c := &Config{values: map[string]ConfigValue{}}
+	for _, s := range settings {
+		c.values[s.key] = ConfigValue{Key: s.key, Value: s.def, Source: SourceDefault}
+	}
+
+	userPath := l.ConfigPath()
+	user, err := LoadUserConfigAt(userPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.apply(&user.Settings, SourceUser, userPath); err != nil {
+		return nil, err
+	}
+	c.Auth, c.authFrom = user.Auth, userPath
+
+	projectPath := l.TomlPath()
+	if manifest, err := LoadToml(projectPath); err == nil && manifest.Settings != nil {
+		if err := c.apply(manifest.Settings, SourceProject, projectPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, name := s.env(); v != "" {
+			if err := c.Override(s.key, v, SourceEnv, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *Config) apply(layer *Settings, source ConfigSource, origin string) error {
+	for _, s := range settings {
+		if v := s.get(layer); v != "" {
+			if err := c.Override(s.key, v, source, origin); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Override sets key on top of every layer loaded so far.
+func (c *Config) Override(key, value string, source ConfigSource, origin string) error {
+	s, ok := findSetting(key)
+	if !ok {
+		return NewError(ErrNotFound, "unknown config key %q", key)
+	}
+	v, err := s.check(value)
+	if err != nil {
+		if origin != "" {
+			return fmt.Errorf("invalid %s from %s: %w", key, origin, err)
+		}
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	s.set(&c.Settings, v)
+	c.values[key] = ConfigValue{Key: key, Value: v, Source: source, Origin: origin}
+	return nil
+}
+
+func (c *Config) Get(key string) (ConfigValue, error) {
+	if v, ok := c.values[key]; ok {
+		return v, nil
+	}
+	if host, field, ok := parseAuthKey(key); ok {
+		if v := authField(c.Auth[host], field); v != "" {
+			return ConfigValue{Key: key, Value: v, Source: SourceUser, Origin: c.authFrom}, nil
+		}
+		return ConfigValue{}, NewError(ErrNotFound, "%s is not set", key)
+	}
+	return ConfigValue{}, NewError(ErrNotFound, "unknown config key %q", key)
+}
+
+// Values lists every setting followed by configured registry credentials,
+// with secrets masked.
+func (c *Config) Values() []ConfigValue {
+	var out []ConfigValue
+	for _, s := range settings {
+		out = append(out, c.values[s.key])
+	}
+
+	hosts := make([]string, 0, len(c.Auth))
+	for h := range c.Auth {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	for _, h := range hosts {
+		for _, field := range []string{"token", "username", "password"} {
+			v := authField(c.Auth[h], field)
+			if v == "" {
+				continue
+			}
+			if field != "username" {
+				v = "********"
+			}
+			out = append(out, ConfigValue{Key: "auth." + h + "." + field, Value: v, Source: SourceUser, Origin: c.authFrom})
+		}
+	}
+	return out
+}
+
+func (c *Config) Proxy() string { return c.Settings.Proxy }
+
+func (c *Config) Jobs() int { return c.Settings.Jobs }
+
+func (c *Config) InstallMode() string { return c.Settings.InstallMode }
+
+func (c *Config) Replaces() string { return c.Settings.Replaces }
+
+func (c *Config) VulnDB() string { return c.Settings.VulnDB }
+
+func (c *Config) Color() string { return c.Settings.Color }
+
+// defaultConfig is the configuration made of the built-in defaults only.
+func defaultConfig() *Config {
+	c := &Config{values: map[string]ConfigValue{}}
+	for _, s := range settings {
+		_ = c.Override(s.key, s.def, SourceDefault, "")
+	}
+	return c
+}
+
+// loadConfigOrDefaults is LoadConfig for callers that cannot report an
+// error: an invalid layer falls back to the built-in defaults.
+func loadConfigOrDefaults(l Layout) *Config {
+	c, err := LoadConfig(l)
+	if err != nil {
+		return defaultConfig()
+	}
+	return c
+}
+
+func parseAuthKey(key string) (host, field string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "auth.")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 {
+		return "", "", false
+	}
+	host, field = rest[:i], rest[i+1:]
+	switch field {
+	case "token", "username", "password":
+		return host, field, true
+	}
+	return "", "", false
+}
+
+func authField(a HostAuth, field string) string {
+	switch field {
+	case "token":
+		return a.Token
+	case "username":
+		return a.Username
+	case "password":
+		return a.Password
+	}
+	return ""
+}
+
+func setAuthField(a *HostAuth, field, value string) {
+	switch field {
+	case "token":
+		a.Token = value
+	case "username":
+		a.Username = value
+	case "password":
+		a.Password = value
+	}
+}
+
+func validateSetting(key, value string) (string, error) {
+	s, ok := findSetting(key)
+	if !ok {
+		return "", NewError(ErrNotFound, "unknown config key %q", key)
+	}
+	v, err := s.check(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return v, nil
+}
+
+// SetUserConfig writes key to the user config file. An empty value removes
+// it. Besides settings it accepts auth.<host>.token|username|password.
+func SetUserConfig(path, key, value string) error {
+	cfg, err := LoadUserConfigAt(path)
+	if err != nil {
+		return err
+	}
+
+	if host, field, ok := parseAuthKey(key); ok {
+		if cfg.Auth == nil {
+			cfg.Auth = map[string]HostAuth{}
+		}
+		auth := cfg.Auth[host]
+		setAuthField(&auth, field, value)
+		if auth == (HostAuth{}) {
+			delete(cfg.Auth, host)
+		} else {
+			cfg.Auth[host] = auth
+		}
+	} else {
+		if value != "" {
+			if value, err = validateSetting(key, value); err != nil {
+				return err
+			}
+		} else if _, ok := findSetting(key); !ok {
+			return NewError(ErrNotFound, "unknown config key %q", key)
+		}
+		s, _ := findSetting(key)
+		s.set(&cfg.Settings, value)
+	}
+	return SaveUserConfigAt(path, cfg)
+}
+
+// SetProjectConfig writes key to the [settings] table of a gopkg.toml.
+// Credentials are refused so they never end up in a committed file.
+func SetProjectConfig(tomlPath, key, value string) error {
+	if _, _, ok := parseAuthKey(key); ok {
+		return NewError(ErrConflict, "%s can only be set in the user config", key)
+	}
+	s, ok := findSetting(key)
+	if !ok {
+		return NewError(ErrNotFound, "unknown config key %q", key)
+	}
+
+	manifest, err := LoadToml(tomlPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", tomlPath, err)
+	}
+	if value != "" {
+		if value, err = validateSetting(key, value); err != nil {
+			return err
+		}
+	}
+	if manifest.Settings == nil {
+		manifest.Settings = &Settings{}
+	}
+	s.set(manifest.Settings, value)
+	if *manifest.Settings == (Settings{}) {
+		manifest.Settings = nil
+	}
+	return SaveToml(tomlPath, manifest)
+}
+
+func SaveUserConfigAt(path string, cfg *UserConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return toml.NewEncoder(f).Encode(cfg)
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Constraint is a version requirement from gopkg.toml:
+//
+//	latest            newest release
+//	v1.2.3            exactly v1.2.3
+//	^v1.2.3           >= v1.2.3, < v2.0.0 (< v0.3.0 for v0.x)
+//	~v1.2.3           >= v1.2.3, < v1.3.0
+//	>=v1.2.0 <v1.5.0  every comparator must match
+type Constraint struct {
+	raw   string
+	exact string
+	conds []versionCond
+}
+
+type versionCond struct {
+	op      string
+	version string
+}
+
+func ParseConstraint(s string) (Constraint, error) {
+	s = strings.TrimSpace(s)
+	c := Constraint{raw: s}
+	if s == "" || s == "latest" {
+		return c, nil
+	}
+
+	switch {
+	case strings.HasPrefix(s, "^"):
+		v, err := constraintVersion(s[1:], s)
+		if err != nil {
+			return c, err
+		}
+		c.conds = []versionCond{{">=", v}, {"<", caretUpper(v)}}
+	case strings.HasPrefix(s, "~"):
+		v, err := constraintVersion(s[1:], s)
+		if err != nil {
+			return c, err
+		}
+		c.conds = []versionCond{{">=", v}, {"<", bumpMinor(v)}}
+	case strings.ContainsAny(s, "<>="):
+		for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+			op := strings.TrimRight(field[:len(field)-len(strings.TrimLeft(field, "<>="))], " ")
+			switch op {
+			case ">=", ">", "<=", "<", "=":
+			default:
+				return c, fmt.Errorf("invalid constraint %q: unknown operator in %q", s, field)
+			}
+			v, err := constraintVersion(field[len(op):], s)
+			if err != nil {
+				return c, err
+			}
+			c.conds = append(c.conds, versionCond{op, v})
+		}
+	default:
+		v, err := constraintVersion(s, s)
+		if err != nil {
+			return c, err
+		}
+		c.exact = v
+	}
+	return c, nil
+}
+
+func isConstraintSyntax(s string) bool {
+	return strings.ContainsAny(s, "^~<>=, ")
+}
+
+// ValidateConstraint rejects malformed ranges. Plain strings that are not
+// semantic versions are accepted as proxy queries.
+func ValidateConstraint(s string) error {
+	if _, err := ParseConstraint(s); err != nil && isConstraintSyntax(s) {
+		return err
+	}
+	return nil
+}
+
+func constraintVersion(v, raw string) (string, error) {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	if !semver.IsValid(v) {
+		return "", fmt.Errorf("invalid version %q in constraint %q", v, raw)
+	}
+	return v, nil
+}
+
+func caretUpper(v string) string {
+	major := semver.Major(v)
+	if major == "v0" {
+		return bumpMinor(v)
+	}
+	var n int
+	fmt.Sscanf(major, "v%d", &n)
+	return fmt.Sprintf("v%d.0.0", n+1)
+}
+
+func bumpMinor(v string) string {
+	var major, minor int
+	fmt.Sscanf(semver.MajorMinor(v), "v%d.%d", &major, &minor)
+	return fmt.Sprintf("v%d.%d.0", major, minor+1)
+}
+
+func (c Constraint) String() string { return c.raw }
+
+// Exact returns the pinned version for constraints like "v1.2.3".
+func (c Constraint) Exact() (string, bool) { return c.exact, c.exact != "" }
+
+func (c Constraint) IsLatest() bool { return c.exact == "" && len(c.conds) == 0 }
+
+// IsRange reports whether the constraint admits a range of versions, so
+// updates can move the lock without rewriting it.
+func (c Constraint) IsRange() bool { return len(c.conds) > 0 }
+
+// Floor returns the lowest version the constraint names as a lower bound,
+// or "" when it has none.
+func (c Constraint) Floor() string {
+	if c.exact != "" {
+		return c.exact
+	}
+	floor := ""
+	for _, cond := range c.conds {
+		switch cond.op {
+		case ">=", ">", "=":
+			floor = maxVersion(floor, cond.version)
+		}
+	}
+	return floor
+}
+
+func (c Constraint) Match(v string) bool {
+	if !semver.IsValid(v) {
+		return false
+	}
+	if c.exact != "" {
+		return semver.Compare(v, c.exact) == 0
+	}
+	for _, cond := range c.conds {
+		cmp := semver.Compare(v, cond.version)
+		ok := false
+		switch cond.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		case "=":
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the highest version matching the constraint. Pre-releases
+// are only picked when no release matches.
+func (c Constraint) Select(versions []string) string {
+	best, bestPre := "", ""
+	for _, v := range versions {
+		if !c.Match(v) {
+			continue
+		}
+		if semver.Prerelease(v) != "" {
+			if semver.Compare(v, bestPre) > 0 {
+				bestPre = v
+			}
+		} else if semver.Compare(v, best) > 0 {
+			best = v
+		}
+	}
+	if best == "" {
+		return bestPre
+	}
+	return best
+}
+
+// ResolveConstraint turns a gopkg.toml version into something the proxy can
+// serve directly: "latest" and exact versions pass through, anything else is
+// matched against the module's version list.
+func (p *Proxy) ResolveConstraint(ctx context.Context, module, version string) (string, error) {
+	c, err := ParseConstraint(version)
+	if err != nil {
+		if !isConstraintSyntax(version) {
+			// Branch names and other queries are left to the proxy.
+			return version, nil
+		}
+		return "", &ModuleError{Module: module, Version: version, Kind: ErrNotFound, Err: err}
+	}
+	if c.IsLatest() {
+		return "latest", nil
+	}
+	if v, ok := c.Exact(); ok {
+		return v, nil
+	}
+
+	versions, err := p.Versions(ctx, module)
+	if err != nil {
+		return "", err
+	}
+	if v := c.Select(versions); v != "" {
+		return v, nil
+	}
+	return "", &ModuleError{Module: module, Version: version, Kind: ErrNotFound, Err: fmt.Errorf("no version matches %s", version)}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		in      string
+		exact   string
+		latest  bool
+		isRange bool
+		floor   string
+		wantErr bool
+	}{
+		{in: "", latest: true},
+		{in: "latest", latest: true},
+		{in: "v1.2.3", exact: "v1.2.3", floor: "v1.2.3"},
+		{in: "1.2.3", exact: "v1.2.3", floor: "v1.2.3"},
+		{in: "^v1.2.3", isRange: true, floor: "v1.2.3"},
+		{in: "~1.2.3", isRange: true, floor: "v1.2.3"},
+		{in: ">=v1.2.0 <v1.5.0", isRange: true, floor: "v1.2.0"},
+		{in: ">=1.2.0, <1.5.0", isRange: true, floor: "v1.2.0"},
+		{in: ">v1.0.0 >=v1.1.0", isRange: true, floor: "v1.1.0"},
+		{in: "<v2.0.0", isRange: true, floor: ""},
+		{in: "^", wantErr: true},
+		{in: "^v1.x", wantErr: true},
+		{in: ">=v1.0 <", wantErr: true},
+		{in: "=>v1.0.0", wantErr: true},
+		{in: "!v1.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			c, err := ParseConstraint(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseConstraint(%q) succeeded", tt.in)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			exact, _ := c.Exact()
+			if exact != tt.exact || c.IsLatest() != tt.latest || c.IsRange() != tt.isRange || c.Floor() != tt.floor {
+				t.Errorf("exact %q, latest %v, range %v, floor %q; want %q, %v, %v, %q",
+					exact, c.IsLatest(), c.IsRange(), c.Floor(), tt.exact, tt.latest, tt.isRange, tt.floor)
+			}
+		})
+	}
+}
+
+func TestConstraintMatch(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{"latest", []string{"v0.0.1", "v9.9.9", "v1.0.0-rc.1"}, []string{"master"}},
+		{"v1.2.3", []string{"v1.2.3"}, []string{"v1.2.4", "v1.2.3-rc.1"}},
+		{"^v1.2.3", []string{"v1.2.3", "v1.9.0"}, []string{"v1.2.2", "v2.0.0"}},
+		{"^v0.2.3", []string{"v0.2.3", "v0.2.9"}, []string{"v0.3.0", "v0.2.2"}},
+		{"~v1.2.3", []string{"v1.2.3", "v1.2.9"}, []string{"v1.3.0", "v1.2.0"}},
+		{">=v1.2.0 <v1.5.0", []string{"v1.2.0", "v1.4.9"}, []string{"v1.1.9", "v1.5.0"}},
+		{">v1.0.0 <=v1.1.0", []string{"v1.0.1", "v1.1.0"}, []string{"v1.0.0", "v1.1.1"}},
+		{"=v1.0.0", []string{"v1.0.0"}, []string{"v1.0.1"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range tt.match {
+			if !c.Match(v) {
+				t.Errorf("%q does not match %s", tt.constraint, v)
+			}
+		}
+		for _, v := range tt.noMatch {
+			if c.Match(v) {
+				t.Errorf("%q matches %s", tt.constraint, v)
+			}
+		}
+	}
+}
+
+func TestConstraintSelect(t *testing.T) {
+	versions := []string{"v1.0.0", "v1.2.0", "v1.3.0-rc.1", "v1.2.5", "v2.0.0", "v2.1.0-beta.1"}
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^v1.0.0", "v1.2.5"},
+		{"~v1.2.0", "v1.2.5"},
+		{">=v1.3.0-rc.1 <v2.0.0", "v1.3.0-rc.1"},
+		{">=v2.1.0-alpha", "v2.1.0-beta.1"},
+		{">=v2.0.0", "v2.0.0"},
+		{"^v3.0.0", ""},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Select(versions); got != tt.want {
+			t.Errorf("%q selects %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestValidateConstraint(t *testing.T) {
+	for _, s := range []string{"latest", "v1.2.3", "^1.0.0", ">=v1.0.0 <v2.0.0", "master", "abc123"} {
+		if err := ValidateConstraint(s); err != nil {
+			t.Errorf("ValidateConstraint(%q) = %v", s, err)
+		}
+	}
+	for _, s := range []string{"^", ">=1.0 <", "~x", "v1.0.0 v2.0.0"} {
+		if err := ValidateConstraint(s); err == nil {
+			t.Errorf("ValidateConstraint(%q) succeeded", s)
+		}
+	}
+}
+
+func TestResolveConstraint(t *testing.T) {
+	proxy := newTestProxy(t,
+		testModule{Path: "example.com/lib", Version: "v1.0.0"},
+		testModule{Path: "example.com/lib", Version: "v1.1.0"},
+		testModule{Path: "example.com/lib", Version: "v2.0.0"},
+	)
+	ctx := context.Background()
+	tests := []struct {
+		version string
+		want    string
+		kind    error
+	}{
+		{"latest", "latest", nil},
+		{"v1.0.0", "v1.0.0", nil},
+		{"main", "main", nil},
+		{"^v1.0.0", "v1.1.0", nil},
+		{"^v3.0.0", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, err := proxy.ResolveConstraint(ctx, "example.com/lib", tt.version)
+		if got != tt.want || (tt.kind == nil) != (err == nil) || (tt.kind != nil && !errors.Is(err, tt.kind)) {
+			t.Errorf("ResolveConstraint(%q) = %q, %v, want %q, %v", tt.version, got, err, tt.want, tt.kind)
+		}
+	}
+}
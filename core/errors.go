@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrNetwork   = errors.New("network error")
+	ErrIntegrity = errors.New("integrity check failed")
+	ErrConflict  = errors.New("conflict")
+	ErrPolicy    = errors.New("policy violation")
+	ErrInvalid   = errors.New("invalid argument")
+)
+
+type ModuleError struct {
+	Module  string
+	Version string
+	Kind    error
+	Err     error
+}
+
+func (e *ModuleError) Error() string {
+	target := e.Module
+	if e.Version != "" {
+		target += "@" + e.Version
+	}
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %v", target, e.Kind)
+	}
+	return fmt.Sprintf("%s: %v", target, e.Err)
+}
+
+func (e *ModuleError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func statusError(module, version, what string, status int) error {
+	kind := ErrNetwork
+	if status == http.StatusNotFound || status == http.StatusGone {
+		kind = ErrNotFound
+	}
+	return &ModuleError{
+		Module:  module,
+		Version: version,
+		Kind:    kind,
+		Err:     fmt.Errorf("failed to fetch %s: status %d", what, status),
+	}
+}
+
+func networkError(module, version string, err error) error {
+	return &ModuleError{Module: module, Version: version, Kind: ErrNetwork, Err: err}
+}
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+func NewError(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
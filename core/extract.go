@@ -2,6 +2,7 @@ package core
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -9,17 +10,8 @@ import (
 	"strings"
 )
 
-func ExtractZip(zipPath, vendorDir, version string, quiet, global bool) error {
-	var destRoot string
-	if global {
-		destRoot = filepath.Join(os.Getenv("HOME"), ".gopkg", "modules", "github.com")
-	} else {
-		destRoot = filepath.Join("gopkg_modules", "github.com")
-	}
-
-	if !quiet {
-		fmt.Printf("\033[34mℹ️ Extracting to %s...\033[0m\n", destRoot)
-	}
+func ExtractZip(ctx context.Context, zipPath, module, version, dest string) error {
+	report(ctx, Event{Kind: EventExtractStart, Module: module, Version: version, Path: dest})
 
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
@@ -30,28 +22,33 @@ func ExtractZip(zipPath, vendorDir, version string, quiet, global bool) error {
 	if len(r.File) == 0 {
 		return fmt.Errorf("archive is empty")
 	}
-	rootDir := strings.SplitN(r.File[0].Name, "/", 2)[0]
-	if rootDir == "" {
-		return fmt.Errorf("could not determine archive root directory")
+
+	prefix, err := archiveRoot(r.File, module, version)
+	if err != nil {
+		return err
 	}
 
-	if err := os.MkdirAll(destRoot, 0755); err != nil {
+	tmpDir := dest + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
 		return err
 	}
+	defer os.RemoveAll(tmpDir)
 
 	extractedFiles := 0
 	for _, f := range r.File {
-		if !strings.HasPrefix(f.Name, rootDir+"/") {
-			continue
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		relPath := strings.TrimPrefix(f.Name, rootDir+"/")
-		if relPath == "" {
+		relPath, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || relPath == "" {
 			continue
 		}
 
-		destPath := filepath.Join(destRoot, relPath)
-
-		if !strings.HasPrefix(destPath, filepath.Clean(destRoot)+string(os.PathSeparator)) {
+		destPath := filepath.Join(tmpDir, filepath.FromSlash(relPath))
+		if !strings.HasPrefix(destPath, filepath.Clean(tmpDir)+string(os.PathSeparator)) {
 			continue
 		}
 
@@ -62,43 +59,64 @@ func ExtractZip(zipPath, vendorDir, version string, quiet, global bool) error {
 			continue
 		}
 
-		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		if err := extractFile(f, destPath); err != nil {
 			return err
 		}
+		extractedFiles++
+	}
 
-		src, err := f.Open()
-		if err != nil {
-			return err
-		}
-		dst, err := os.Create(destPath)
-		if err != nil {
-			src.Close()
-			return err
-		}
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpDir, dest); err != nil {
+		return fmt.Errorf("rename failed: %w", err)
+	}
 
-		_, err = io.Copy(dst, src)
-		src.Close()
-		dst.Close()
+	report(ctx, Event{Kind: EventExtractDone, Module: module, Version: version, Files: extractedFiles, Path: dest})
+	return nil
+}
 
-		if err != nil {
-			return err
-		}
+func extractFile(f *zip.File, destPath string) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
 
-		extractedFiles++
+	src, err := f.Open()
+	if err != nil {
+		return err
 	}
+	defer src.Close()
 
-	if version != "" {
-		oldPath := "./" + vendorDir + "@" + version
-		newPath := "./" + vendorDir
+	dst, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
 
-		_ = os.RemoveAll(newPath)
-		if err := os.Rename(oldPath, newPath); err != nil {
-			return fmt.Errorf("rename failed: %w", err)
-		}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
 	}
+	return err
+}
 
-	if !quiet {
-		fmt.Printf("\033[32m✔️ Extracted %d files to %s\033[0m\n", extractedFiles, destRoot)
+// archiveRoot finds the module@version/ directory every file lives under.
+// Proxies use the module path, but some archives carry a different casing or
+// leading directory entries, so fall back to the first entry with the marker.
+func archiveRoot(files []*zip.File, module, version string) (string, error) {
+	prefix := module + "@" + version + "/"
+	marker := "@" + version + "/"
+	for _, f := range files {
+		if strings.HasPrefix(f.Name, prefix) {
+			return prefix, nil
+		}
 	}
-	return nil
+	for _, f := range files {
+		if idx := strings.Index(f.Name, marker); idx >= 0 {
+			return f.Name[:idx+len(marker)], nil
+		}
+	}
+	return "", fmt.Errorf("could not determine archive root directory")
 }
@@ -1,71 +1,204 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"golang.org/x/mod/sumdb/dirhash"
 )
 
-func DownloadModuleZip(module, version string) (string, error) {
+func DownloadModuleZip(ctx context.Context, module, version, sum string) (string, string, error) {
+	return DefaultProxy().DownloadZip(ctx, GetCacheDir(), module, version, sum)
+}
+
+func (p *Proxy) DownloadZip(ctx context.Context, cacheDir, module, version, sum string) (string, string, error) {
 	if version == "" {
-		return "", fmt.Errorf("version is required")
+		return "", "", fmt.Errorf("version is required")
 	}
 
 	safeName := strings.ReplaceAll(module, "/", "_")
-	homeDir, _ := os.UserHomeDir()
-
-	baseGopkgDir := filepath.Join(homeDir, ".gopkg")
-	cacheDir := filepath.Join(baseGopkgDir, "cache")
 	cacheFile := filepath.Join(cacheDir, fmt.Sprintf("%s@%s.zip", safeName, version))
+	hashFile := cacheFile + "hash"
+	partialFile := cacheFile + ".partial"
 
 	if _, err := os.Stat(cacheFile); err == nil {
-		fmt.Printf("\033[36m📦 Using cached %s@%s\033[0m\n", module, version)
-		return cacheFile, nil
+		cachedSum, err := verifyCachedZip(cacheFile, hashFile, sum)
+		if err == nil {
+			report(ctx, Event{Kind: EventCacheHit, Module: module, Version: version, Path: cacheFile})
+			return cacheFile, cachedSum, nil
+		}
+		report(ctx, Event{
+			Kind:    EventWarning,
+			Module:  module,
+			Version: version,
+			Message: fmt.Sprintf("cached zip is corrupt (%v), refetching", err),
+		})
+		os.Remove(cacheFile)
+		os.Remove(hashFile)
 	}
 
-	url := fmt.Sprintf("https://proxy.golang.org/%s/@v/%s.zip", module, version)
-
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create cache dir: %w", err)
+		return "", "", fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	url := p.moduleURL(module, "@v/"+version+".zip")
+	if err := p.downloadPartial(ctx, url, partialFile, module, version); err != nil {
+		return "", "", err
 	}
 
-	resp, err := http.Get(url)
+	gotSum, err := dirhash.HashZip(partialFile, dirhash.Hash1)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch zip: %w", err)
+		os.Remove(partialFile)
+		return "", "", &ModuleError{Module: module, Version: version, Kind: ErrIntegrity, Err: fmt.Errorf("downloaded zip is corrupt: %w", err)}
+	}
+	if sum != "" && gotSum != sum {
+		os.Remove(partialFile)
+		return "", "", &ModuleError{Module: module, Version: version, Kind: ErrIntegrity, Err: fmt.Errorf("checksum mismatch: expected %s, got %s", sum, gotSum)}
+	}
+
+	if err := os.WriteFile(hashFile, []byte(gotSum), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write zip hash: %w", err)
+	}
+	if err := os.Rename(partialFile, cacheFile); err != nil {
+		return "", "", fmt.Errorf("failed to move zip into cache: %w", err)
+	}
+
+	report(ctx, Event{Kind: EventDownloadDone, Module: module, Version: version, Path: cacheFile})
+	return cacheFile, gotSum, nil
+}
+
+func verifyCachedZip(cacheFile, hashFile, sum string) (string, error) {
+	got, err := dirhash.HashZip(cacheFile, dirhash.Hash1)
+	if err != nil {
+		return "", err
+	}
+	if recorded, err := os.ReadFile(hashFile); err == nil && strings.TrimSpace(string(recorded)) != got {
+		return "", fmt.Errorf("hash does not match recorded %s", strings.TrimSpace(string(recorded)))
+	}
+	if sum != "" && got != sum {
+		return "", fmt.Errorf("hash does not match locked %s", sum)
+	}
+	if _, err := os.Stat(hashFile); os.IsNotExist(err) {
+		_ = os.WriteFile(hashFile, []byte(got), 0644)
+	}
+	return got, nil
+}
+
+// downloadPartial fetches url into partialFile, resuming from whatever a
+// previous interrupted run left behind when the server honors Range requests.
+func (p *Proxy) downloadPartial(ctx context.Context, url, partialFile, module, version string) error {
+	var offset int64
+	if info, err := os.Stat(partialFile); err == nil {
+		offset = info.Size()
+	}
+
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := p.get(ctx, url, header)
+	if err != nil {
+		return networkError(module, version, fmt.Errorf("failed to fetch zip: %w", err))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch zip: status %d", resp.StatusCode)
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && contentRangeStart(resp) == offset:
+		flags |= os.O_APPEND
+	case offset > 0 && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable):
+		// The server cannot resume where the partial file ends, so start
+		// over with a full download.
+		resp.Body.Close()
+		if err := os.Remove(partialFile); err != nil {
+			return fmt.Errorf("failed to remove partial download: %w", err)
+		}
+		return p.downloadPartial(ctx, url, partialFile, module, version)
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return statusError(module, version, "zip", resp.StatusCode)
+	}
+
+	out, err := os.OpenFile(partialFile, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file: %w", err)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
 	}
 
-	out, err := os.Create(cacheFile)
+	report(ctx, Event{Kind: EventDownloadStart, Module: module, Version: version, Bytes: offset, Total: total})
+	progress := &progressWriter{ctx: ctx, module: module, version: version, written: offset, total: total}
+	written, err := io.Copy(io.MultiWriter(out, progress), resp.Body)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to create zip file: %w", err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return networkError(module, version, fmt.Errorf("failed to save zip: %w", err))
 	}
-	defer out.Close()
 
-	fmt.Printf("\033[34m⬇️ Downloading %s@%s...\033[0m\n", module, version)
-	progress := &progressWriter{total: resp.ContentLength}
-	if _, err = io.Copy(io.MultiWriter(out, progress), resp.Body); err != nil {
-		return "", fmt.Errorf("failed to save zip: %w", err)
+	if total >= 0 && offset+written != total {
+		os.Remove(partialFile)
+		return &ModuleError{Module: module, Version: version, Kind: ErrIntegrity, Err: fmt.Errorf("incomplete download: got %d of %d bytes", offset+written, total)}
 	}
-	fmt.Printf("\r\033[32m✔️ Downloaded and cached %s@%s\033[0m\n", module, version)
-	return cacheFile, nil
+	return nil
 }
 
-type progressWriter struct {
-	written int64
-	total   int64
+func contentRangeStart(resp *http.Response) int64 {
+	cr := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, _, ok := strings.Cut(cr, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
 }
 
-func (p *progressWriter) Write(b []byte) (int, error) {
-	n := len(b)
-	p.written += int64(n)
-	percent := float64(p.written) / float64(p.total) * 100
-	fmt.Printf("\r\033[36mProgress: %.1f%%\033[0m", percent)
-	return n, nil
+// ModFile returns a module's go.mod, from the cache when present. With
+// offline set a cache miss is reported as ErrNotFound instead of fetching.
+func (p *Proxy) ModFile(ctx context.Context, cacheDir, module, version string, offline bool) ([]byte, error) {
+	safeName := strings.ReplaceAll(module, "/", "_")
+	cacheFile := filepath.Join(cacheDir, fmt.Sprintf("%s@%s.mod", safeName, version))
+
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		return data, nil
+	}
+	if offline {
+		return nil, &ModuleError{Module: module, Version: version, Kind: ErrNotFound, Err: fmt.Errorf("go.mod is not cached")}
+	}
+
+	resp, err := p.get(ctx, p.moduleURL(module, "@v/"+version+".mod"), nil)
+	if err != nil {
+		return nil, networkError(module, version, fmt.Errorf("failed to fetch go.mod: %w", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, statusError(module, version, "go.mod", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, networkError(module, version, fmt.Errorf("failed to read go.mod: %w", err))
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err == nil {
+		_ = os.WriteFile(cacheFile, data, 0644)
+	}
+	return data, nil
 }
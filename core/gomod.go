@@ -2,19 +2,102 @@ package core
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+
+	"golang.org/x/mod/modfile"
 )
 
-func AddReplaceToGoMod(module, localPath, version string) error {
-	module = strings.Split(module, "@")[0]
+type GoMod struct {
+	Dir string
+}
 
-	_ = exec.Command("go", "mod", "edit", "-require", fmt.Sprintf("%s@%s", module, version)).Run()
+func (g GoMod) edit(args ...string) error {
+	cmd := exec.Command("go", append([]string{"mod", "edit"}, args...)...)
+	cmd.Dir = g.Dir
+	return cmd.Run()
+}
 
-	cmd := exec.Command("go", "mod", "edit", "-replace", fmt.Sprintf("%s=%s", module, localPath))
+func (g GoMod) Exists() bool {
+	_, err := os.Stat(filepath.Join(g.Dir, "go.mod"))
+	return err == nil
+}
+
+func (g GoMod) Init(module string) error {
+	cmd := exec.Command("go", "mod", "init", module)
+	cmd.Dir = g.Dir
 	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go mod init %s: %w", module, err)
+	}
+	return nil
+}
+
+func (g GoMod) AddReplace(module, localPath, version string) error {
+	module = strings.Split(module, "@")[0]
+
+	_ = g.edit("-require", fmt.Sprintf("%s@%s", module, version))
+
+	if err := g.edit("-replace", fmt.Sprintf("%s=%s", module, localPath)); err != nil {
 		return fmt.Errorf("failed to add replace for %s: %w", module, err)
 	}
 
 	return nil
 }
+
+// Require records module@version without a replace, for workspace members
+// whose replaces live in go.work.
+func (g GoMod) Require(module, version string) error {
+	if err := g.edit("-require", fmt.Sprintf("%s@%s", module, version)); err != nil {
+		return fmt.Errorf("failed to require %s: %w", module, err)
+	}
+	return nil
+}
+
+// Requires reports whether go.mod has a require line for module.
+func (g GoMod) Requires(module string) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(g.Dir, "go.mod"))
+	if err != nil {
+		return false, err
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range f.Require {
+		if r.Mod.Path == module {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ModulePath returns the module path declared in go.mod.
+func (g GoMod) ModulePath() (string, error) {
+	data, err := os.ReadFile(filepath.Join(g.Dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	path := modfile.ModulePath(data)
+	if path == "" {
+		return "", fmt.Errorf("%s has no module directive", filepath.Join(g.Dir, "go.mod"))
+	}
+	return path, nil
+}
+
+func (g GoMod) DropReplace(module string) error {
+	_ = g.edit("-droprequire=" + module)
+	if err := g.edit("-dropreplace=" + module); err != nil {
+		return fmt.Errorf("failed to drop replace for %s: %w", module, err)
+	}
+	return nil
+}
+
+func AddReplaceToGoMod(module, localPath, version string) error {
+	return GoMod{}.AddReplace(module, localPath, version)
+}
+
+func RemoveReplaceFromGoMod(module string) error {
+	return GoMod{}.DropReplace(module)
+}
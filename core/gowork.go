@@ -0,0 +1,171 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// GoWork edits the go.work file in Dir with `go work`.
+type GoWork struct {
+	Dir string
+}
+
+func (w GoWork) Path() string {
+	return filepath.Join(w.Dir, "go.work")
+}
+
+func (w GoWork) Exists() bool {
+	_, err := os.Stat(w.Path())
+	return err == nil
+}
+
+func (w GoWork) run(args ...string) error {
+	cmd := exec.Command("go", append([]string{"work"}, args...)...)
+	cmd.Dir = w.Dir
+	cmd.Env = append(os.Environ(), "GOWORK=")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("go work %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("go work %s: %w", args[0], err)
+	}
+	return nil
+}
+
+func (w GoWork) edit(args ...string) error {
+	return w.run(append(append([]string{"edit"}, args...), "go.work")...)
+}
+
+// Init creates go.work if it does not exist yet.
+func (w GoWork) Init() error {
+	if w.Exists() {
+		return nil
+	}
+	return w.run("init")
+}
+
+// Load parses go.work.
+func (w GoWork) Load() (*modfile.WorkFile, error) {
+	data, err := os.ReadFile(w.Path())
+	if err != nil {
+		return nil, err
+	}
+	return modfile.ParseWork(w.Path(), data, nil)
+}
+
+// Use adds dirs, relative to Dir, to the workspace.
+func (w GoWork) Use(dirs ...string) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		args = append(args, "-use="+d)
+	}
+	return w.edit(args...)
+}
+
+// AddReplace points module at localPath for every module in the workspace.
+// The version is ignored; go.work replaces apply to all versions. The file
+// is edited directly because `go work edit` reads the @ of a module@version
+// directory as a version.
+func (w GoWork) AddReplace(module, localPath, version string) error {
+	wf, err := w.Load()
+	if err == nil {
+		err = wf.AddReplace(module, "", localPath, "")
+	}
+	if err == nil {
+		wf.Cleanup()
+		err = os.WriteFile(w.Path(), modfile.Format(wf.Syntax), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add replace for %s: %w", module, err)
+	}
+	return nil
+}
+
+func (w GoWork) DropReplace(module string) error {
+	if err := w.edit("-dropreplace=" + module); err != nil {
+		return fmt.Errorf("failed to drop replace for %s: %w", module, err)
+	}
+	return nil
+}
+
+// pruneReplaces drops replaces that point into modulesDir for modules that
+// are no longer in keep, leaving replaces the user wrote alone.
+func (w GoWork) pruneReplaces(modulesDir string, keep map[string]string) error {
+	wf, err := w.Load()
+	if err != nil {
+		return err
+	}
+	prefix := "./" + filepath.ToSlash(modulesDir) + "/"
+	for _, r := range wf.Replace {
+		if _, ok := keep[r.Old.Path]; ok || !strings.HasPrefix(r.New.Path, prefix) {
+			continue
+		}
+		if err := w.DropReplace(r.Old.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replacer returns what install uses to point the toolchain at installed
+// modules: go.mod by default, or go.work when the replaces setting is
+// "go.work". In that mode go.mod is left untouched.
+func (p *Project) replacer(ctx context.Context) (replacer, error) {
+	gomod := p.GoMod()
+	if p.config().Replaces() != "go.work" {
+		return gomod, nil
+	}
+	work := GoWork{Dir: p.Layout.Root}
+	if err := work.Init(); err != nil {
+		return nil, err
+	}
+	if err := work.Use("."); err != nil {
+		return nil, err
+	}
+	return workReplacer{ctx: ctx, gomod: gomod, work: work}, nil
+}
+
+type workReplacer struct {
+	ctx   context.Context
+	gomod GoMod
+	work  GoWork
+}
+
+// AddReplace writes the replace to go.work only. Modules go.mod does not
+// require yet are reported, requiring them is left to `go get`.
+func (r workReplacer) AddReplace(module, localPath, version string) error {
+	if err := r.work.AddReplace(module, localPath, version); err != nil {
+		return err
+	}
+	if required, err := r.gomod.Requires(module); err == nil && !required {
+		report(r.ctx, Event{Kind: EventWarning, Module: module, Version: version,
+			Message: fmt.Sprintf("not required by go.mod, run `go get %s@%s`", module, version)})
+	}
+	return nil
+}
+
+// dropReplace removes module from go.work, if there is one, and from go.mod
+// unless replaces live in go.work.
+func (p *Project) dropReplace(module string) error {
+	if p.config().Replaces() != "go.work" {
+		if err := p.GoMod().DropReplace(module); err != nil {
+			return err
+		}
+	}
+	if work := (GoWork{Dir: p.Layout.Root}); work.Exists() {
+		return work.DropReplace(module)
+	}
+	return nil
+}
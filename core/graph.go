@@ -0,0 +1,240 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Graph is the module requirement graph rooted at the manifest's
+// dependencies. Every reachable module@version is a node, as in
+// `go mod graph`; Selected holds the version minimal version selection picks
+// for each module path.
+type Graph struct {
+	Name     string
+	Roots    []module.Version
+	Edges    map[module.Version][]module.Version
+	Selected map[string]string
+	Linked   map[string]string
+	Missing  []module.Version
+}
+
+type GraphOptions struct {
+	// Offline only reads go.mod files that are installed or cached.
+	Offline bool
+}
+
+func (p *Project) LoadGraph(ctx context.Context, opts GraphOptions) (*Graph, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	return p.loadGraph(ctx, cfg, p.lockMap(), opts)
+}
+
+// loadGraph builds the graph for cfg with the direct dependencies pinned to
+// their entries in lockMap.
+func (p *Project) loadGraph(ctx context.Context, cfg *GopkgToml, lockMap map[string]LockEntry, opts GraphOptions) (*Graph, error) {
+	g := &Graph{
+		Name:     cfg.Name,
+		Edges:    map[module.Version][]module.Version{},
+		Selected: map[string]string{},
+		Linked:   map[string]string{},
+	}
+	installed := map[module.Version]string{}
+
+	for _, m := range sortedKeys(cfg.Dependencies) {
+		root := module.Version{Path: m, Version: lockMap[m].Resolved}
+		if link, ok := cfg.Links[m]; ok {
+			g.Linked[m] = link
+			installed[root] = filepath.Join(link, "go.mod")
+		} else if root.Version == "" {
+			if !semver.IsValid(cfg.Dependencies[m]) {
+				continue
+			}
+			root.Version = cfg.Dependencies[m]
+		} else {
+			installed[root] = filepath.Join(p.Layout.ModuleDir(m, root.Version), "go.mod")
+		}
+		g.Roots = append(g.Roots, root)
+	}
+
+	jobs := p.config().Jobs()
+	if jobs <= 0 {
+		jobs = DefaultJobs
+	}
+
+	visited := map[module.Version]bool{}
+	level := append([]module.Version(nil), g.Roots...)
+	for len(level) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		reqs := make([][]module.Version, len(level))
+		errs := make([]error, len(level))
+		sem := make(chan struct{}, jobs)
+		var wg sync.WaitGroup
+		for i, mv := range level {
+			visited[mv] = true
+			wg.Add(1)
+			go func(i int, mv module.Version) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				reqs[i], errs[i] = p.requirements(ctx, mv, installed[mv], opts.Offline)
+			}(i, mv)
+		}
+		wg.Wait()
+
+		var next []module.Version
+		for i, mv := range level {
+			if errs[i] != nil {
+				g.Missing = append(g.Missing, mv)
+				continue
+			}
+			g.Edges[mv] = reqs[i]
+			for _, r := range reqs[i] {
+				if !visited[r] {
+					visited[r] = true
+					next = append(next, r)
+				}
+			}
+		}
+		level = next
+	}
+
+	for mv := range visited {
+		if semver.Compare(mv.Version, g.Selected[mv.Path]) > 0 || g.Selected[mv.Path] == "" {
+			g.Selected[mv.Path] = mv.Version
+		}
+	}
+	// Direct dependencies are pinned by their replace directive.
+	for _, root := range g.Roots {
+		g.Selected[root.Path] = root.Version
+	}
+	return g, nil
+}
+
+func (p *Project) requirements(ctx context.Context, mv module.Version, local string, offline bool) ([]module.Version, error) {
+	var data []byte
+	var err error
+	if local != "" {
+		data, err = os.ReadFile(local)
+	}
+	if local == "" || err != nil {
+		if mv.Version == "" {
+			return nil, fmt.Errorf("go.mod for %s is not available", mv.Path)
+		}
+		data, err = p.Proxy.ModFile(ctx, p.Layout.Cache, mv.Path, mv.Version, offline)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := modfile.ParseLax(mv.Path+"@"+mv.Version+"/go.mod", data, nil)
+	if err != nil {
+		return nil, &ModuleError{Module: mv.Path, Version: mv.Version, Kind: ErrIntegrity, Err: err}
+	}
+	reqs := make([]module.Version, 0, len(f.Require))
+	for _, r := range f.Require {
+		reqs = append(reqs, r.Mod)
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		if reqs[i].Path != reqs[j].Path {
+			return reqs[i].Path < reqs[j].Path
+		}
+		return semver.Compare(reqs[i].Version, reqs[j].Version) < 0
+	})
+	return reqs, nil
+}
+
+// Paths returns requirement paths from a root to any version of target, up
+// to limit paths (0 means no limit). The number of paths grows quickly with
+// the graph, so the walk takes the requirements closest to target first and
+// the result is ordered shortest first: the first path is a shortest one.
+func (g *Graph) Paths(target string, limit int) [][]module.Version {
+	reverse := map[module.Version][]module.Version{}
+	var queue []module.Version
+	for from, reqs := range g.Edges {
+		for _, to := range reqs {
+			reverse[to] = append(reverse[to], from)
+			if to.Path == target {
+				queue = append(queue, to)
+			}
+		}
+	}
+	for _, root := range g.Roots {
+		if root.Path == target {
+			queue = append(queue, root)
+		}
+	}
+	// dist is how many requirements separate a module from target; modules
+	// that cannot reach it have none.
+	dist := map[module.Version]int{}
+	for _, mv := range queue {
+		dist[mv] = 0
+	}
+	for len(queue) > 0 {
+		mv := queue[0]
+		queue = queue[1:]
+		for _, from := range reverse[mv] {
+			if _, ok := dist[from]; !ok {
+				dist[from] = dist[mv] + 1
+				queue = append(queue, from)
+			}
+		}
+	}
+	closest := func(mvs []module.Version) []module.Version {
+		var next []module.Version
+		for _, mv := range mvs {
+			if _, ok := dist[mv]; ok {
+				next = append(next, mv)
+			}
+		}
+		sort.SliceStable(next, func(i, j int) bool { return dist[next[i]] < dist[next[j]] })
+		return next
+	}
+
+	var paths [][]module.Version
+	var walk func(path []module.Version, onPath map[module.Version]bool)
+	walk = func(path []module.Version, onPath map[module.Version]bool) {
+		if limit > 0 && len(paths) >= limit {
+			return
+		}
+		mv := path[len(path)-1]
+		if mv.Path == target {
+			paths = append(paths, append([]module.Version(nil), path...))
+			return
+		}
+		onPath[mv] = true
+		for _, r := range closest(g.Edges[mv]) {
+			if !onPath[r] {
+				walk(append(path, r), onPath)
+			}
+		}
+		delete(onPath, mv)
+	}
+
+	for _, root := range closest(g.Roots) {
+		walk([]module.Version{root}, map[module.Version]bool{})
+	}
+	sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+	return paths
+}
+
+func (g *Graph) IsRoot(path string) bool {
+	for _, r := range g.Roots {
+		if r.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func modVersion(path, version string) module.Version {
+	return module.Version{Path: path, Version: version}
+}
+
+func TestGraphPaths(t *testing.T) {
+	target := modVersion("example.com/sys", "v0.1.0")
+	a, b, c := modVersion("example.com/a", "v1.0.0"), modVersion("example.com/b", "v1.0.0"), modVersion("example.com/c", "v1.0.0")
+	g := &Graph{
+		Roots: []module.Version{a, c},
+		Edges: map[module.Version][]module.Version{
+			a: {b, modVersion("example.com/unrelated", "v1.0.0")},
+			b: {modVersion("example.com/sys", "v0.2.0")},
+			c: {target},
+		},
+	}
+
+	paths := g.Paths("example.com/sys", 0)
+	if len(paths) != 2 {
+		t.Fatalf("paths = %v", paths)
+	}
+	if len(paths[0]) != 2 || paths[0][0] != c || paths[0][1] != target {
+		t.Errorf("first path = %v, want the shortest c → sys", paths[0])
+	}
+	if len(paths[1]) != 3 || paths[1][0] != a || paths[1][1] != b {
+		t.Errorf("second path = %v", paths[1])
+	}
+	if got := g.Paths("example.com/none", 0); len(got) != 0 {
+		t.Errorf("paths to a missing module = %v", got)
+	}
+	if got := g.Paths("example.com/a", 0); len(got) != 1 || len(got[0]) != 1 {
+		t.Errorf("paths to a root = %v", got)
+	}
+}
+
+// Twenty layers of four modules that each require all four of the next
+// layer have 4^20 paths to the bottom; a limit must stop the walk early.
+func TestGraphPathsLimit(t *testing.T) {
+	const layers, width = 20, 4
+	node := func(layer, i int) module.Version {
+		return modVersion(fmt.Sprintf("example.com/l%d/m%d", layer, i), "v1.0.0")
+	}
+	target := modVersion("example.com/sys", "v0.1.0")
+	g := &Graph{Edges: map[module.Version][]module.Version{}}
+	for i := 0; i < width; i++ {
+		g.Roots = append(g.Roots, node(0, i))
+	}
+	for layer := 0; layer < layers; layer++ {
+		for i := 0; i < width; i++ {
+			from := node(layer, i)
+			if layer == layers-1 {
+				g.Edges[from] = []module.Version{target}
+				continue
+			}
+			for j := 0; j < width; j++ {
+				g.Edges[from] = append(g.Edges[from], node(layer+1, j))
+			}
+		}
+	}
+	// A shortcut from the last root makes a much shorter path.
+	last := g.Roots[width-1]
+	g.Edges[last] = append(g.Edges[last], target)
+
+	paths := g.Paths("example.com/sys", 10)
+	if len(paths) != 10 {
+		t.Fatalf("got %d paths, want 10", len(paths))
+	}
+	if len(paths[0]) != 2 || paths[0][0] != last {
+		t.Errorf("first path = %v, want the shortcut", paths[0])
+	}
+}
@@ -0,0 +1,204 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// VersionChange is one dependency whose version moved during a command.
+// Versions are the locked ones, or the declared constraint for modules that
+// are not locked yet; an empty side means the module was added or removed.
+type VersionChange struct {
+	Module string `json:"module" yaml:"module"`
+	Before string `json:"before,omitempty" yaml:"before,omitempty"`
+	After  string `json:"after,omitempty" yaml:"after,omitempty"`
+}
+
+type ChangeKind string
+
+const (
+	ChangeAdded      ChangeKind = "added"
+	ChangeRemoved    ChangeKind = "removed"
+	ChangeUpgraded   ChangeKind = "upgraded"
+	ChangeDowngraded ChangeKind = "downgraded"
+	// ChangeChanged covers edits that cannot be ordered, such as a constraint
+	// being replaced by a pseudo-version.
+	ChangeChanged ChangeKind = "changed"
+)
+
+func (c VersionChange) Kind() ChangeKind {
+	switch {
+	case c.Before == "":
+		return ChangeAdded
+	case c.After == "":
+		return ChangeRemoved
+	case !semver.IsValid(c.Before) || !semver.IsValid(c.After):
+		return ChangeChanged
+	case semver.Compare(c.After, c.Before) > 0:
+		return ChangeUpgraded
+	case semver.Compare(c.After, c.Before) < 0:
+		return ChangeDowngraded
+	}
+	return ChangeChanged
+}
+
+// HistoryEntry is one record in .gopkg/history.jsonl.
+type HistoryEntry struct {
+	ID         int             `json:"id" yaml:"id"`
+	Time       time.Time       `json:"time" yaml:"time"`
+	User       string          `json:"user,omitempty" yaml:"user,omitempty"`
+	Command    string          `json:"command" yaml:"command"`
+	Snapshot   string          `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`
+	LockBefore string          `json:"lock_before,omitempty" yaml:"lock_before,omitempty"`
+	LockAfter  string          `json:"lock_after,omitempty" yaml:"lock_after,omitempty"`
+	Changes    []VersionChange `json:"changes" yaml:"changes"`
+}
+
+// projectState is what a history record compares before and after a command.
+type projectState struct {
+	versions map[string]string
+	lockHash string
+}
+
+func (p *Project) state() projectState {
+	st := projectState{versions: map[string]string{}, lockHash: fileHash(p.Layout.LockPath())}
+	if cfg, err := p.LoadManifest(); err == nil {
+		for module, version := range cfg.Dependencies {
+			st.versions[module] = version
+		}
+	}
+	for module, entry := range p.lockMap() {
+		if _, declared := st.versions[module]; declared && entry.Resolved != "" {
+			st.versions[module] = entry.Resolved
+		}
+	}
+	return st
+}
+
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func diffStates(before, after projectState) []VersionChange {
+	modules := map[string]string{}
+	for m := range before.versions {
+		modules[m] = ""
+	}
+	for m := range after.versions {
+		modules[m] = ""
+	}
+	changes := []VersionChange{}
+	for _, m := range sortedKeys(modules) {
+		if b, a := before.versions[m], after.versions[m]; b != a {
+			changes = append(changes, VersionChange{Module: m, Before: b, After: a})
+		}
+	}
+	return changes
+}
+
+func (p *Project) historyPath() string {
+	return filepath.Join(p.Layout.StateDir(), "history.jsonl")
+}
+
+// recordHistory appends a record for command, comparing the project with
+// the state captured before it ran.
+func (p *Project) recordHistory(command, snapshot string, before projectState) error {
+	after := p.state()
+	entries, err := p.History()
+	if err != nil {
+		return err
+	}
+	entry := HistoryEntry{
+		ID:         1,
+		Time:       time.Now().UTC(),
+		User:       currentUser(),
+		Command:    command,
+		Snapshot:   snapshot,
+		LockBefore: before.lockHash,
+		LockAfter:  after.lockHash,
+		Changes:    diffStates(before, after),
+	}
+	if n := len(entries); n > 0 {
+		entry.ID = entries[n-1].ID + 1
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.historyPath()), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p.historyPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// History returns every recorded command, oldest first.
+func (p *Project) History() ([]HistoryEntry, error) {
+	f, err := os.Open(p.historyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("%s:%d: %w", p.historyPath(), line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// HistoryEntry looks up a record by the ID shown in `gopkg history`.
+func (p *Project) HistoryEntry(id int) (*HistoryEntry, error) {
+	entries, err := p.History()
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, NewError(ErrNotFound, "history entry %d not found", id)
+}
@@ -0,0 +1,190 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultHeaderTimeout  = 30 * time.Second
+	DefaultMaxRetries     = 3
+	DefaultBaseDelay      = 500 * time.Millisecond
+	DefaultMaxDelay       = 30 * time.Second
+)
+
+type Client struct {
+	http       *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	hooks      []func(*http.Request) error
+}
+
+type Option func(*Client)
+
+func New(opts ...Option) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   DefaultConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = DefaultConnectTimeout
+	transport.ResponseHeaderTimeout = DefaultHeaderTimeout
+
+	c := &Client{
+		http:       &http.Client{Transport: transport},
+		maxRetries: DefaultMaxRetries,
+		baseDelay:  DefaultBaseDelay,
+		maxDelay:   DefaultMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithConnectTimeout bounds dialing and the TLS handshake.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if t, ok := c.http.Transport.(*http.Transport); ok && d > 0 {
+			t.DialContext = (&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext
+			t.TLSHandshakeTimeout = d
+		}
+	}
+}
+
+// WithHeaderTimeout bounds the wait for response headers. Bodies are not
+// limited so large downloads are only bounded by the request context.
+func WithHeaderTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if t, ok := c.http.Transport.(*http.Transport); ok && d > 0 {
+			t.ResponseHeaderTimeout = d
+		}
+	}
+}
+
+func WithRetries(n int) Option {
+	return func(c *Client) {
+		if n >= 0 {
+			c.maxRetries = n
+		}
+	}
+}
+
+func WithBackoff(base, max time.Duration) Option {
+	return func(c *Client) {
+		if base > 0 {
+			c.baseDelay = base
+		}
+		if max > 0 {
+			c.maxDelay = max
+		}
+	}
+}
+
+func WithRequestHook(hook func(*http.Request) error) Option {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.http.Transport = rt
+	}
+}
+
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends req, retrying network errors, 429 and 5xx responses with
+// exponential backoff and jitter. A Retry-After header overrides the backoff;
+// one longer than the maximum delay returns the response right away instead
+// of waiting. Requests must not carry a body that cannot be replayed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	for _, hook := range c.hooks {
+		if err := hook(req); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := c.http.Do(req.Clone(ctx))
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		if attempt >= c.maxRetries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if ra, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if ra > c.maxDelay {
+					return resp, nil
+				}
+				delay = ra
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.baseDelay << attempt
+	if d <= 0 || d > c.maxDelay {
+		d = c.maxDelay
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,424 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/mod/module"
+)
+
+type InstallOptions struct {
+	Frozen bool
+	// Only restricts the install to these modules; every other dependency
+	// keeps its current lock entry untouched.
+	Only []string
+	// Jobs is how many modules are downloaded and extracted at once.
+	// Zero uses the configured value.
+	Jobs int
+	// Add declares these modules in gopkg.toml, unless they already are,
+	// as part of the install so a failure removes them again.
+	Add map[string]string
+	// pins resolves modules to these versions instead of their declared
+	// constraint, for updates that move the lock but keep gopkg.toml.
+	pins map[string]string
+}
+
+type InstallResult struct {
+	Modules     []ModuleResult
+	LockUpdated bool
+	// RolledBack is set when a failure restored the project files.
+	RolledBack bool
+}
+
+func (r *InstallResult) Errors() []error {
+	var errs []error
+	for _, m := range r.Modules {
+		if m.Err != nil {
+			errs = append(errs, m.Err)
+		}
+	}
+	return errs
+}
+
+// Install installs every dependency in the manifest, reusing locked versions
+// when the declared version has not changed, and rewrites the lockfile. It
+// runs as a transaction: if any module fails or the install is cancelled,
+// gopkg.toml, gopkg.lock and the Go module files are restored.
+func (p *Project) Install(ctx context.Context, opts InstallOptions) (*InstallResult, error) {
+	tx, err := p.Begin("install")
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.install(ctx, opts)
+	return result, p.finishInstall(ctx, tx, result, err)
+}
+
+// finishInstall commits or rolls back tx depending on how the install went.
+func (p *Project) finishInstall(ctx context.Context, tx *Transaction, result *InstallResult, err error) error {
+	var modules []ModuleResult
+	failed := err != nil
+	if result != nil {
+		modules = result.Modules
+		failed = failed || len(result.Errors()) > 0
+	}
+	rolledBack, txErr := tx.finish(ctx, failed, modules)
+	if result != nil && rolledBack {
+		result.RolledBack, result.LockUpdated = true, false
+	}
+	return errors.Join(err, txErr)
+}
+
+func (p *Project) install(ctx context.Context, opts InstallOptions) (*InstallResult, error) {
+	var cfg *GopkgToml
+	if opts.Frozen {
+		var err error
+		cfg, err = p.LoadManifest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+		}
+	} else {
+		var created bool
+		var err error
+		cfg, created, err = p.LoadOrCreateManifest()
+		if err != nil {
+			return nil, err
+		}
+		for module, version := range opts.Add {
+			if _, ok := cfg.Dependencies[module]; !ok {
+				cfg.Dependencies[module] = version
+				created = true
+			}
+		}
+		if created {
+			if err := p.SaveManifest(cfg); err != nil {
+				return nil, fmt.Errorf("failed to update gopkg.toml: %w", err)
+			}
+		}
+	}
+
+	// The lock at a workspace root is shared with the members, so it is
+	// always installed as a whole.
+	ws, err := p.workspace(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if ws != nil {
+		return ws.install(ctx, opts)
+	}
+
+	gomod := p.GoMod()
+	if !gomod.Exists() {
+		root, _ := filepath.Abs(p.Layout.Root)
+		_ = gomod.Init(filepath.Base(root))
+	}
+	r, err := p.replacer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.installManifest(ctx, cfg, opts, r)
+}
+
+// replacer points the Go toolchain at an installed module, through go.mod
+// or go.work replace directives.
+type replacer interface {
+	AddReplace(module, localPath, version string) error
+}
+
+// installManifest installs cfg's dependencies into the project's modules
+// directory, wires them up with r and writes the lockfile.
+func (p *Project) installManifest(ctx context.Context, cfg *GopkgToml, opts InstallOptions, r replacer) (*InstallResult, error) {
+	lockMap := p.lockMap()
+
+	if opts.Frozen {
+		if len(cfg.Links) > 0 {
+			linked := make([]string, 0, len(cfg.Links))
+			for m := range cfg.Links {
+				linked = append(linked, m)
+			}
+			sort.Strings(linked)
+			return nil, NewError(ErrConflict, "refusing --frozen install while modules are linked: %s (run `gopkg unlink <module>` first)", strings.Join(linked, ", "))
+		}
+		hash, err := p.manifestHash(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if lock, err := ReadLockFileAt(p.Layout.LockPath()); err == nil && lock.ManifestHash != "" && lock.ManifestHash != hash {
+			return nil, NewError(ErrConflict, "gopkg.lock is out of date: gopkg.toml changed since it was written")
+		}
+		for m, v := range cfg.Dependencies {
+			if entry, ok := lockMap[m]; !ok || entry.Version != v {
+				return nil, NewError(ErrConflict, "gopkg.lock is out of date: %s@%s is not locked", m, v)
+			}
+		}
+	}
+
+	modules := sortedKeys(cfg.Dependencies)
+	only := map[string]bool{}
+	for _, m := range opts.Only {
+		only[m] = true
+	}
+	selected := func(m string) bool { return len(only) == 0 || only[m] }
+	index, count := map[string]int{}, 0
+	for _, m := range modules {
+		if selected(m) {
+			count++
+			index[m] = count
+		}
+	}
+
+	// Locked modules were checked against the license policy when they were
+	// installed, but only while gopkg.toml is unchanged since the lock was
+	// written can install rely on that.
+	hash, err := p.manifestHash(cfg)
+	if err != nil {
+		return nil, err
+	}
+	lockCurrent := false
+	if lock, err := ReadLockFileAt(p.Layout.LockPath()); err == nil {
+		lockCurrent = lock.ManifestHash == hash
+	}
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = p.config().Jobs()
+	}
+	if jobs <= 0 {
+		jobs = DefaultJobs
+	}
+
+	type task struct {
+		res     ModuleResult
+		entry   LockEntry
+		err     error
+		started bool
+	}
+	tasks := make([]task, len(modules))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	// Downloads and extraction run concurrently; go.mod and the lockfile
+	// are updated afterwards in manifest order.
+	for i, module := range modules {
+		version := cfg.Dependencies[module]
+		tasks[i].res = ModuleResult{Module: module, Declared: version}
+		if _, ok := cfg.Links[module]; ok || !selected(module) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, t *task) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			t.started = true
+			report(ctx, Event{Kind: EventInstallStart, Module: module, Version: version, Index: index[module], Count: count})
+			var extracted bool
+			t.entry, extracted, t.err = p.fetchModule(ctx, module, version, opts.pins[module], lockMap, &t.res)
+			if t.err == nil && (extracted || t.res.Status != StatusLocked || !lockCurrent) {
+				t.err = cfg.Policy.checkLicense(module, t.res.Resolved, p.Layout.ModuleDir(module, t.res.Resolved))
+			}
+		}(i, &tasks[i])
+	}
+	wg.Wait()
+
+	result := &InstallResult{Modules: []ModuleResult{}}
+	var newLock []LockEntry
+
+	for i := range tasks {
+		t := &tasks[i]
+		module := t.res.Module
+		previous, locked := lockMap[module]
+
+		if !selected(module) {
+			if locked {
+				newLock = append(newLock, previous)
+			}
+			continue
+		}
+
+		if linkPath, ok := cfg.Links[module]; ok {
+			if ctx.Err() != nil {
+				continue
+			}
+			report(ctx, Event{Kind: EventInstallStart, Module: module, Version: t.res.Declared, Path: linkPath, Index: index[module], Count: count})
+			t.res.Path = linkPath
+			if err := r.AddReplace(module, linkPath, linkVersion(previous.Resolved)); err != nil {
+				t.res.Status, t.res.Err = StatusFailed, err
+			} else {
+				t.res.Status = StatusLinked
+			}
+			if locked {
+				newLock = append(newLock, previous)
+			}
+			result.Modules = append(result.Modules, t.res)
+			continue
+		}
+
+		if !t.started {
+			if locked {
+				newLock = append(newLock, previous)
+			}
+			continue
+		}
+		if t.err == nil && ctx.Err() == nil {
+			if err := r.AddReplace(module, p.Layout.ReplacePath(module, t.res.Resolved), t.res.Resolved); err != nil {
+				t.err = fmt.Errorf("replace: %w", err)
+			}
+		}
+		if t.err != nil {
+			// Keep what was installed before so the lock still describes
+			// the files on disk.
+			t.res.Status, t.res.Err = StatusFailed, t.err
+			if locked {
+				newLock = append(newLock, previous)
+			}
+		} else {
+			newLock = append(newLock, t.entry)
+		}
+		result.Modules = append(result.Modules, t.res)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("install cancelled: %w", err)
+	}
+
+	if !opts.Frozen {
+		if err := p.writeLock(cfg, p.withRequirements(ctx, cfg, newLock)); err != nil {
+			return result, err
+		}
+		result.LockUpdated = true
+	}
+	return result, nil
+}
+
+// fetchModule resolves version, or pin when set, and makes sure the module is
+// extracted into the modules directory. It reports whether it had to extract.
+func (p *Project) fetchModule(ctx context.Context, module, version, pin string, lockMap map[string]LockEntry, res *ModuleResult) (LockEntry, bool, error) {
+	var meta *ModuleMetadata
+	var sum string
+
+	if lockEntry, ok := lockMap[module]; ok && lockEntry.Version == version && (pin == "" || pin == lockEntry.Resolved) {
+		res.Resolved = lockEntry.Resolved
+		sum = lockEntry.Sum
+		meta = &ModuleMetadata{
+			Version: lockEntry.Resolved,
+			Time:    parseLockTime(lockEntry.ResolvedTime),
+			Hash:    lockEntry.Hash,
+		}
+		res.Status = StatusLocked
+	} else {
+		query := pin
+		if query == "" {
+			resolved, err := p.Proxy.ResolveConstraint(ctx, module, version)
+			if err != nil {
+				return LockEntry{}, false, err
+			}
+			query = resolved
+		}
+		var err error
+		meta, err = p.Proxy.Metadata(ctx, module, query)
+		if err != nil {
+			return LockEntry{}, false, err
+		}
+		res.Resolved = meta.Version
+		res.Status = StatusInstalled
+	}
+
+	localPath := p.Layout.ModuleDir(module, res.Resolved)
+	_, statErr := os.Stat(filepath.Join(localPath, "go.mod"))
+	extracted := statErr != nil
+	if extracted {
+		zipPath, zipSum, err := p.Proxy.DownloadZip(ctx, p.Layout.Cache, module, res.Resolved, sum)
+		if err != nil {
+			return LockEntry{}, false, fmt.Errorf("download: %w", err)
+		}
+		sum = zipSum
+		if err := ExtractZip(ctx, zipPath, module, res.Resolved, localPath); err != nil {
+			return LockEntry{}, false, fmt.Errorf("extract: %w", err)
+		}
+	}
+
+	return LockEntry{
+		Name:         module,
+		Version:      version,
+		Resolved:     res.Resolved,
+		Source:       p.Proxy.Source(),
+		Hash:         meta.Hash,
+		Sum:          sum,
+		ResolvedTime: meta.Time.Format(time.RFC3339),
+	}, extracted, nil
+}
+
+// withRequirements fills in the requires edges of the direct dependencies
+// and adds an indirect entry for every transitive module at the version
+// minimal version selection picks.
+func (p *Project) withRequirements(ctx context.Context, cfg *GopkgToml, entries []LockEntry) []LockEntry {
+	direct := map[string]LockEntry{}
+	for _, e := range entries {
+		if !e.Indirect {
+			direct[e.Name] = e
+		}
+	}
+	g, err := p.loadGraph(ctx, cfg, direct, GraphOptions{})
+	if err != nil {
+		return entries
+	}
+	for _, mv := range g.Missing {
+		report(ctx, Event{Kind: EventWarning, Module: mv.Path, Version: mv.Version, Message: "go.mod unavailable, its requirements are not locked"})
+	}
+
+	requires := func(mv module.Version) []string {
+		var reqs []string
+		for _, r := range g.Edges[mv] {
+			reqs = append(reqs, r.String())
+		}
+		return reqs
+	}
+	locked := make([]LockEntry, 0, len(g.Selected))
+	for _, name := range sortedKeys(g.Selected) {
+		version := g.Selected[name]
+		if e, ok := direct[name]; ok {
+			e.Requires = requires(module.Version{Path: name, Version: e.Resolved})
+			locked = append(locked, e)
+			delete(direct, name)
+			continue
+		}
+		if _, declared := cfg.Dependencies[name]; declared || version == "" {
+			continue
+		}
+		locked = append(locked, LockEntry{
+			Name:     name,
+			Resolved: version,
+			Indirect: true,
+			Requires: requires(module.Version{Path: name, Version: version}),
+		})
+	}
+	// Links and modules whose go.mod could not be read are kept as they are.
+	for _, e := range direct {
+		locked = append(locked, e)
+	}
+	return locked
+}
+
+func parseLockTime(t string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return parsed
+}
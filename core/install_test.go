@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallNestedModules(t *testing.T) {
+	for _, jobs := range []int{1, 4} {
+		proxy := newTestProxy(t,
+			testModule{Path: "example.com/a", Version: "v1.0.0", Files: map[string]string{"a.go": "package a\n"}},
+			testModule{Path: "example.com/a/b", Version: "v1.0.0", Files: map[string]string{"b.go": "package b\n"}},
+		)
+		p := newTestProject(t, proxy, `name = "app"
+
+[dependencies]
+"example.com/a" = "v1.0.0"
+"example.com/a/b" = "v1.0.0"
+`)
+		result, err := p.Install(context.Background(), InstallOptions{Jobs: jobs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs := result.Errors(); len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		for _, f := range []string{
+			filepath.Join(p.Layout.ModuleDir("example.com/a", "v1.0.0"), "a.go"),
+			filepath.Join(p.Layout.ModuleDir("example.com/a/b", "v1.0.0"), "b.go"),
+		} {
+			if _, err := os.Stat(f); err != nil {
+				t.Errorf("jobs %d: %v", jobs, err)
+			}
+		}
+		gomod := readFile(t, filepath.Join(p.Layout.Root, "go.mod"))
+		if !strings.Contains(gomod, "example.com/a/b => ./gopkg_modules/example.com/a/b@v1.0.0") {
+			t.Errorf("jobs %d: go.mod:\n%s", jobs, gomod)
+		}
+	}
+}
+
+func TestInstallRemovesSupersededVersion(t *testing.T) {
+	proxy := newTestProxy(t,
+		testModule{Path: "example.com/lib", Version: "v1.0.0"},
+		testModule{Path: "example.com/lib", Version: "v1.1.0"},
+	)
+	p := newTestProject(t, proxy, `name = "app"
+
+[dependencies]
+"example.com/lib" = "v1.0.0"
+`)
+	ctx := context.Background()
+	if _, err := p.Install(ctx, InstallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Update(ctx, UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(p.Layout.ModuleDir("example.com/lib", "v1.1.0")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(p.Layout.ModuleDir("example.com/lib", "v1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("v1.0.0 left behind: %v", err)
+	}
+}
+
+func TestGoWorkReplaceVersionedDir(t *testing.T) {
+	work := GoWork{Dir: t.TempDir()}
+	writeFile(t, work.Path(), "go 1.21\n")
+	for _, dir := range []string{"./gopkg_modules/example.com/a@v1.0.0", "./gopkg_modules/example.com/a@v1.1.0"} {
+		if err := work.AddReplace("example.com/a", dir, "v1.0.0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wf, err := work.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wf.Replace) != 1 || wf.Replace[0].New.Path != "./gopkg_modules/example.com/a@v1.1.0" || wf.Replace[0].New.Version != "" {
+		t.Errorf("go.work:\n%s", readFile(t, work.Path()))
+	}
+}
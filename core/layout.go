@@ -0,0 +1,148 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+)
+
+type Layout struct {
+	Root    string
+	Home    string
+	Cache   string
+	Config  string
+	Modules string
+	Global  bool
+}
+
+// DefaultLayout resolves gopkg's directories from the environment:
+//
+//   - GOPKG_HOME (or --home) puts everything under one directory.
+//   - Otherwise data and cache live in an existing ~/.gopkg for
+//     compatibility, and new installs follow XDG: data in
+//     $XDG_DATA_HOME/gopkg and cache in $XDG_CACHE_HOME/gopkg.
+//   - The user config is $XDG_CONFIG_HOME/gopkg/config.toml, falling back to
+//     ~/.gopkg/config.toml while only the legacy file exists.
+//   - GOPKG_CACHE and GOPKG_MODULES override the cache and the global
+//     modules directory on their own.
+func DefaultLayout(global bool) Layout {
+	l := Layout{Root: ".", Global: global}
+
+	if home := os.Getenv("GOPKG_HOME"); home != "" {
+		l.Home, l.Cache, l.Config = home, filepath.Join(home, "cache"), home
+	} else {
+		l.Config = filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "gopkg")
+		if legacy := legacyHome(); legacy != "" {
+			l.Home, l.Cache = legacy, filepath.Join(legacy, "cache")
+			if !exists(filepath.Join(l.Config, "config.toml")) && exists(filepath.Join(legacy, "config.toml")) {
+				l.Config = legacy
+			}
+		} else {
+			l.Home = filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), "gopkg")
+			l.Cache = filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), "gopkg")
+		}
+	}
+	l.Modules = filepath.Join(l.Home, "modules")
+
+	if cache := os.Getenv("GOPKG_CACHE"); cache != "" {
+		l.Cache = cache
+	}
+	if modules := os.Getenv("GOPKG_MODULES"); modules != "" {
+		l.Modules = modules
+	}
+	return l
+}
+
+func userHome() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.Getenv("HOME")
+	}
+	return home
+}
+
+func legacyHome() string {
+	dir := filepath.Join(userHome(), ".gopkg")
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return ""
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(userHome(), filepath.FromSlash(fallback))
+}
+
+func (l Layout) TomlPath() string {
+	if l.Global {
+		return filepath.Join(l.Home, "gopkg.toml")
+	}
+	return filepath.Join(l.Root, "gopkg.toml")
+}
+
+func (l Layout) LockPath() string {
+	if l.Global {
+		return filepath.Join(l.Home, "gopkg.lock")
+	}
+	return filepath.Join(l.Root, "gopkg.lock")
+}
+
+func (l Layout) ModulesDir() string {
+	if l.Global {
+		return l.Modules
+	}
+	return filepath.Join(l.Root, "gopkg_modules")
+}
+
+// ModuleDir is where a module version is extracted. Like the Go module
+// cache it is named module@version, so a module nested under another
+// module's path, such as cloud.google.com/go/storage under
+// cloud.google.com/go, never lands inside the other's directory.
+func (l Layout) ModuleDir(module, version string) string {
+	return filepath.Join(l.ModulesDir(), filepath.FromSlash(module)+"@"+version)
+}
+
+// ReplacePath is the go.mod replace target for an installed module: relative
+// to the project for local installs, absolute for global ones.
+func (l Layout) ReplacePath(module, version string) string {
+	dir := l.ModuleDir(module, version)
+	if l.Global {
+		if abs, err := filepath.Abs(dir); err == nil {
+			return abs
+		}
+		return dir
+	}
+	rel, err := filepath.Rel(l.Root, dir)
+	if err != nil {
+		return dir
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// StateDir holds gopkg's own bookkeeping for a project, such as snapshots.
+func (l Layout) StateDir() string {
+	if l.Global {
+		return l.Home
+	}
+	return filepath.Join(l.Root, ".gopkg")
+}
+
+// VulnDBDir holds the vulnerability database downloaded for offline audits.
+func (l Layout) VulnDBDir() string {
+	return filepath.Join(l.Home, "vulndb")
+}
+
+func (l Layout) LinkRegistryPath() string {
+	return filepath.Join(l.Home, "links.toml")
+}
+
+func (l Layout) ConfigPath() string {
+	return filepath.Join(l.Config, "config.toml")
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// UnknownLicense is reported for license files no rule matches.
+const UnknownLicense = "UNKNOWN"
+
+// licenseRule identifies a license by its title, which the license text
+// opens with, or by phrases that must all appear in its normalized text.
+// Titles are only looked for near the top: full license texts name other
+// licenses further down (GPL-3.0 section 13 names the AGPL, MPL-2.0 lists
+// the GPL family as Secondary Licenses), so a title found there says
+// nothing about the file.
+type licenseRule struct {
+	id      string
+	title   string
+	phrases []string
+}
+
+// titleWords is how many words at the top of a license text are searched
+// for its title, leaving room for a copyright line or a project name above.
+const titleWords = 64
+
+var licenseRules = []licenseRule{
+	{id: "AGPL-3.0", title: "gnu affero general public license version 3"},
+	{id: "LGPL-3.0", title: "gnu lesser general public license version 3"},
+	{id: "LGPL-2.1", title: "gnu lesser general public license version 2 1"},
+	{id: "GPL-3.0", title: "gnu general public license version 3"},
+	{id: "GPL-2.0", title: "gnu general public license version 2"},
+	{id: "MPL-2.0", title: "mozilla public license version 2 0"},
+	{id: "EPL-2.0", title: "eclipse public license v 2 0"},
+	{id: "Apache-2.0", title: "apache license version 2 0"},
+	{id: "BSL-1.0", title: "boost software license version 1 0"},
+	{id: "CC0-1.0", title: "cc0 1 0 universal"},
+
+	// A file holding only the notice a license asks projects to include.
+	{id: "AGPL-3.0", phrases: []string{"under the terms of the gnu affero general public license as published by the free software foundation either version 3"}},
+	{id: "LGPL-3.0", phrases: []string{"under the terms of the gnu lesser general public license as published by the free software foundation either version 3"}},
+	{id: "LGPL-2.1", phrases: []string{"under the terms of the gnu lesser general public license as published by the free software foundation either version 2 1"}},
+	{id: "GPL-3.0", phrases: []string{"under the terms of the gnu general public license as published by the free software foundation either version 3"}},
+	{id: "GPL-2.0", phrases: []string{"under the terms of the gnu general public license as published by the free software foundation either version 2"}},
+	{id: "Apache-2.0", phrases: []string{"licensed under the apache license version 2 0"}},
+
+	{id: "BSD-3-Clause", phrases: []string{"redistribution and use in source and binary forms", "neither the name of"}},
+	{id: "BSD-2-Clause", phrases: []string{"redistribution and use in source and binary forms", "this list of conditions and the following disclaimer"}},
+	{id: "MIT", phrases: []string{"permission is hereby granted free of charge to any person obtaining a copy", "the above copyright notice and this permission notice shall be included"}},
+	{id: "ISC", phrases: []string{"permission to use copy modify and or distribute this software for any purpose with or without fee is hereby granted"}},
+	{id: "Zlib", phrases: []string{"altered source versions must be plainly marked as such", "this notice may not be removed or altered from any source distribution"}},
+	{id: "Unlicense", phrases: []string{"this is free and unencumbered software released into the public domain"}},
+}
+
+var (
+	spdxTag        = regexp.MustCompile(`(?i)SPDX-License-Identifier:\s*([^\s*/]+(?:\s+(?:OR|AND|WITH)\s+[^\s*/]+)*)`)
+	nonAlphanumRun = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// ClassifyLicense returns the SPDX identifier of a license text: the
+// SPDX-License-Identifier tag when there is one, else the first matching
+// rule, else UnknownLicense.
+func ClassifyLicense(text string) string {
+	if m := spdxTag.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	words := strings.Fields(nonAlphanumRun.ReplaceAllString(strings.ToLower(text), " "))
+	normalized := " " + strings.Join(words, " ") + " "
+	head := " " + strings.Join(words[:min(len(words), titleWords)], " ") + " "
+	for _, rule := range licenseRules {
+		matched := true
+		if rule.title != "" {
+			matched = strings.Contains(head, " "+rule.title+" ")
+		}
+		for _, phrase := range rule.phrases {
+			matched = matched && strings.Contains(normalized, " "+phrase+" ")
+		}
+		if matched {
+			return rule.id
+		}
+	}
+	return UnknownLicense
+}
+
+// isLicenseFile matches LICENSE, LICENCE, COPYING and UNLICENSE files with
+// any suffix, such as LICENSE.md or LICENSE-APACHE.
+func isLicenseFile(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ModuleLicense is the result of scanning one module's top-level license files.
+type ModuleLicense struct {
+	Module   string   `json:"module" yaml:"module"`
+	Version  string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Licenses []string `json:"licenses" yaml:"licenses"`
+	Files    []string `json:"files" yaml:"files"`
+	// Violation explains why the license policy rejects the module.
+	Violation string `json:"violation,omitempty" yaml:"violation,omitempty"`
+	Err       error  `json:"-" yaml:"-"`
+}
+
+// License renders the detected licenses, e.g. "Apache-2.0 AND MIT".
+func (m ModuleLicense) License() string {
+	if len(m.Licenses) == 0 {
+		return UnknownLicense
+	}
+	return strings.Join(m.Licenses, " AND ")
+}
+
+// DetectLicenses classifies the license files at the top of dir. A module
+// without any reports no licenses.
+func DetectLicenses(dir string) ([]string, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := map[string]string{}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !isLicenseFile(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, e.Name())
+		found[ClassifyLicense(string(data))] = ""
+	}
+	sort.Strings(files)
+	return sortedKeys(found), files, nil
+}
+
+// Licenses scans every declared module, installed or linked.
+func (p *Project) Licenses() ([]ModuleLicense, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	lockMap := p.lockMap()
+
+	results := []ModuleLicense{}
+	for _, module := range sortedKeys(cfg.Dependencies) {
+		res := ModuleLicense{Module: module, Version: lockMap[module].Resolved, Licenses: []string{}, Files: []string{}}
+		dir := p.Layout.ModuleDir(module, res.Version)
+		if link, ok := cfg.Links[module]; ok {
+			dir = filepath.Join(p.Layout.Root, link)
+			if filepath.IsAbs(link) {
+				dir = link
+			}
+		}
+		licenses, files, err := DetectLicenses(dir)
+		if err != nil {
+			res.Err = &ModuleError{Module: module, Version: res.Version, Kind: ErrNotFound, Err: fmt.Errorf("not installed, run `gopkg install`")}
+		} else {
+			res.Licenses, res.Files = licenses, files
+			res.Violation = cfg.Policy.licenseViolation(licenses)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// checkLicense enforces the license policy on a resolved or extracted module.
+func (pol *Policy) checkLicense(module, version, dir string) error {
+	if !pol.HasLicenseRules() {
+		return nil
+	}
+	licenses, _, err := DetectLicenses(dir)
+	if err != nil {
+		return err
+	}
+	if v := pol.licenseViolation(licenses); v != "" {
+		return &ModuleError{Module: module, Version: version, Kind: ErrPolicy, Err: fmt.Errorf("%s", v)}
+	}
+	return nil
+}
+
+// licenseViolation returns why licenses break the policy, or "" when they
+// don't. Every license found must be allowed, when there is an allow list,
+// and none may be denied. A module without a license file is unknown.
+func (pol *Policy) licenseViolation(licenses []string) string {
+	if !pol.HasLicenseRules() {
+		return ""
+	}
+	if len(licenses) == 0 {
+		licenses = []string{UnknownLicense}
+	}
+	for _, l := range licenses {
+		if v := pol.expressionViolation(l); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// expressionViolation handles SPDX expressions: one OR alternative has to
+// pass, and every AND part of it.
+func (pol *Policy) expressionViolation(expr string) string {
+	first := ""
+	for _, alt := range strings.Split(expr, " OR ") {
+		v := pol.idsViolation(strings.Split(alt, " AND "))
+		if v == "" {
+			return ""
+		}
+		if first == "" {
+			first = v
+		}
+	}
+	return first
+}
+
+// idsViolation returns why the first of ids that breaks the policy does.
+func (pol *Policy) idsViolation(ids []string) string {
+	for _, id := range ids {
+		id = strings.Trim(id, "() ")
+		if containsFold(pol.DeniedLicenses, id) {
+			return fmt.Sprintf("license %s is denied by policy", id)
+		}
+		if len(pol.AllowedLicenses) > 0 && !containsFold(pol.AllowedLicenses, id) {
+			return fmt.Sprintf("license %s is not in allowed_licenses", id)
+		}
+	}
+	return ""
+}
+
+// HasLicenseRules reports whether the policy restricts licenses.
+func (pol *Policy) HasLicenseRules() bool {
+	return pol != nil && (len(pol.AllowedLicenses) > 0 || len(pol.DeniedLicenses) > 0)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
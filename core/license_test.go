@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Every file in testdata/licenses is named after the SPDX id it classifies as.
+func TestClassifyLicenseFixtures(t *testing.T) {
+	entries, err := os.ReadDir("testdata/licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		t.Run(e.Name(), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata/licenses", e.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ClassifyLicense(string(data)); got != e.Name() {
+				t.Errorf("ClassifyLicense = %q, want %q", got, e.Name())
+			}
+		})
+	}
+}
+
+func TestClassifyLicenseSPDXTag(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"// SPDX-License-Identifier: MIT\n", "MIT"},
+		{"/* SPDX-License-Identifier: GPL-2.0 OR MIT */", "GPL-2.0 OR MIT"},
+		{"# spdx-license-identifier: Apache-2.0 AND BSD-3-Clause\nmore text", "Apache-2.0 AND BSD-3-Clause"},
+	}
+	for _, tt := range tests {
+		if got := ClassifyLicense(tt.text); got != tt.want {
+			t.Errorf("ClassifyLicense(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyLicenseNotices(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "GPL notice",
+			text: "Copyright (C) 2024 Example Authors\n\nThis program is free software: you can redistribute it and/or modify\nit under the terms of the GNU General Public License as published by\nthe Free Software Foundation, either version 3 of the License, or\n(at your option) any later version.\n",
+			want: "GPL-3.0",
+		},
+		{
+			name: "LGPL 2.1 notice",
+			text: "This library is free software; you can redistribute it and/or\nmodify it under the terms of the GNU Lesser General Public\nLicense as published by the Free Software Foundation; either\nversion 2.1 of the License, or (at your option) any later version.\n",
+			want: "LGPL-2.1",
+		},
+		{
+			name: "Apache notice",
+			text: "Copyright 2024 Example Authors\n\nLicensed under the Apache License, Version 2.0 (the \"License\");\nyou may not use this file except in compliance with the License.\n",
+			want: "Apache-2.0",
+		},
+		{
+			name: "title below a project name",
+			text: "The Example Project\nCopyright (C) 2024 Example Authors\n\n                    GNU GENERAL PUBLIC LICENSE\n                       Version 2, June 1991\n",
+			want: "GPL-2.0",
+		},
+		{
+			name: "another license named far from the top",
+			text: "Example terms.\n" + strings.Repeat("Lorem ipsum dolor sit amet. ", 20) + "\nGNU Affero General Public License version 3\n",
+			want: UnknownLicense,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyLicense(tt.text); got != tt.want {
+				t.Errorf("ClassifyLicense = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLicenseViolation(t *testing.T) {
+	allow := &Policy{AllowedLicenses: []string{"MIT", "Apache-2.0"}}
+	deny := &Policy{DeniedLicenses: []string{"GPL-3.0", "AGPL-3.0"}}
+	tests := []struct {
+		name     string
+		policy   *Policy
+		licenses []string
+		want     string
+	}{
+		{"nil policy", nil, []string{"GPL-3.0"}, ""},
+		{"empty policy", &Policy{}, []string{"GPL-3.0"}, ""},
+		{"allowed", allow, []string{"MIT"}, ""},
+		{"allowed case-insensitive", allow, []string{"mit"}, ""},
+		{"not allowed", allow, []string{"ISC"}, "license ISC is not in allowed_licenses"},
+		{"no license file", allow, nil, "license UNKNOWN is not in allowed_licenses"},
+		{"every file must pass", allow, []string{"MIT", "ISC"}, "license ISC is not in allowed_licenses"},
+		{"denied", deny, []string{"AGPL-3.0"}, "license AGPL-3.0 is denied by policy"},
+		{"not denied", deny, []string{"MIT"}, ""},
+		{"OR takes any alternative", allow, []string{"GPL-2.0 OR MIT"}, ""},
+		{"OR reports the first alternative", allow, []string{"GPL-2.0 OR ISC"}, "license GPL-2.0 is not in allowed_licenses"},
+		{"AND needs every part", allow, []string{"MIT AND ISC"}, "license ISC is not in allowed_licenses"},
+		{"AND with a denied later part", deny, []string{"MIT AND GPL-3.0"}, "license GPL-3.0 is denied by policy"},
+		{"AND inside OR", allow, []string{"(MIT AND ISC) OR Apache-2.0"}, ""},
+		{"AND inside OR failing", deny, []string{"(MIT AND GPL-3.0) OR AGPL-3.0"}, "license GPL-3.0 is denied by policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.licenseViolation(tt.licenses); got != tt.want {
+				t.Errorf("licenseViolation(%q) = %q, want %q", tt.licenses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLicenseNilPolicy(t *testing.T) {
+	var pol *Policy
+	if pol.HasLicenseRules() {
+		t.Error("nil policy has license rules")
+	}
+	// The directory does not exist: a nil policy must not even look.
+	if err := pol.checkLicense("example.com/m", "v1.0.0", filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("checkLicense with nil policy = %v", err)
+	}
+}
+
+func TestInstallLicenseViolationRollsBack(t *testing.T) {
+	mit, err := os.ReadFile("testdata/licenses/MIT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpl, err := os.ReadFile("testdata/licenses/GPL-3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := newTestProxy(t,
+		testModule{Path: "example.com/mit", Version: "v1.0.0", Files: map[string]string{"LICENSE": string(mit)}},
+		testModule{Path: "example.com/gpl", Version: "v1.0.0", Files: map[string]string{"COPYING": string(gpl)}},
+	)
+	p := newTestProject(t, proxy, `name = "app"
+
+[dependencies]
+"example.com/mit" = "v1.0.0"
+
+[policy]
+denied_licenses = ["GPL-3.0"]
+`)
+	ctx := context.Background()
+	if _, err := p.Install(ctx, InstallOptions{}); err != nil {
+		t.Fatalf("first install: %v", err)
+	}
+	lock := readFile(t, p.Layout.LockPath())
+	gomod := readFile(t, filepath.Join(p.Layout.Root, "go.mod"))
+
+	if err := p.Add("example.com/gpl", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	toml := readFile(t, p.Layout.TomlPath())
+	result, err := p.Install(ctx, InstallOptions{})
+	if err == nil && result != nil {
+		err = errors.Join(result.Errors()...)
+	}
+	var me *ModuleError
+	if !errors.As(err, &me) || me.Kind != ErrPolicy || me.Module != "example.com/gpl" {
+		t.Fatalf("install error = %v, want a policy error for example.com/gpl", err)
+	}
+
+	if got := readFile(t, p.Layout.LockPath()); got != lock {
+		t.Errorf("gopkg.lock changed:\n%s", got)
+	}
+	if got := readFile(t, filepath.Join(p.Layout.Root, "go.mod")); got != gomod {
+		t.Errorf("go.mod changed:\n%s", got)
+	}
+	if got := readFile(t, p.Layout.TomlPath()); got != toml {
+		t.Errorf("gopkg.toml changed:\n%s", got)
+	}
+	if _, err := os.Stat(p.Layout.ModuleDir("example.com/gpl", "v1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("example.com/gpl left in gopkg_modules: %v", err)
+	}
+	if _, err := os.Stat(p.Layout.ModuleDir("example.com/mit", "v1.0.0")); err != nil {
+		t.Errorf("example.com/mit removed: %v", err)
+	}
+	if strings.Contains(readFile(t, p.Layout.LockPath()), "example.com/gpl") {
+		t.Error("example.com/gpl locked")
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const localPseudoVersion = "v0.0.0-00010101000000-000000000000"
+
+type LinkRegistry struct {
+	Links map[string]string `toml:"links"`
+}
+
+func ReadModulePath(dir string) (string, error) {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("no go.mod in %s: %w", dir, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			module := strings.Trim(strings.TrimSpace(rest), `"`)
+			if module != "" {
+				return module, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no module directive in %s", filepath.Join(dir, "go.mod"))
+}
+
+func NormalizeLinkPath(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	if path == "." || strings.HasPrefix(path, "../") {
+		return path
+	}
+	return "./" + path
+}
+
+func linkVersion(version string) string {
+	if version == "" || version == "latest" {
+		return localPseudoVersion
+	}
+	return version
+}
+
+func LoadLinkRegistry(path string) (*LinkRegistry, error) {
+	reg := &LinkRegistry{Links: map[string]string{}}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return reg, nil
+	}
+	if _, err := toml.DecodeFile(path, reg); err != nil {
+		return nil, fmt.Errorf("failed to decode link registry: %w", err)
+	}
+	if reg.Links == nil {
+		reg.Links = map[string]string{}
+	}
+	return reg, nil
+}
+
+func SaveLinkRegistry(path string, reg *LinkRegistry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create link registry directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create link registry: %w", err)
+	}
+	defer f.Close()
+	return toml.NewEncoder(f).Encode(reg)
+}
+
+// Link points module at the local directory linkPath and replaces it in
+// go.mod, or go.work, like install does. A module that is not a dependency
+// yet is declared at the local pseudo-version, as a path dependency. Global
+// projects link absolute paths.
+func (p *Project) Link(ctx context.Context, module, linkPath string) (*InstallResult, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	declare := false
+	if _, declared := cfg.Dependencies[module]; !declared {
+		ws, err := p.workspace(cfg)
+		if err != nil {
+			return nil, err
+		}
+		declare = ws == nil || !ws.declares(module)
+	}
+	if p.Layout.Global && !filepath.IsAbs(linkPath) {
+		if linkPath, err = filepath.Abs(linkPath); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := p.Begin("link")
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Links == nil {
+		cfg.Links = map[string]string{}
+	}
+	cfg.Links[module] = linkPath
+	if declare {
+		cfg.Dependencies[module] = localPseudoVersion
+	}
+	var result *InstallResult
+	if err = p.SaveManifest(cfg); err == nil {
+		result, err = p.install(ctx, InstallOptions{Only: []string{module}})
+	}
+	return result, p.finishInstall(ctx, tx, result, err)
+}
+
+// Unlink removes the link for module and installs its locked, or else its
+// declared, version again. A path dependency has no version to go back to.
+func (p *Project) Unlink(ctx context.Context, module string) (*InstallResult, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	if _, ok := cfg.Links[module]; !ok {
+		return nil, &ModuleError{Module: module, Kind: ErrNotFound, Err: fmt.Errorf("not linked")}
+	}
+	if cfg.Dependencies[module] == localPseudoVersion {
+		return nil, &ModuleError{Module: module, Version: localPseudoVersion, Kind: ErrConflict,
+			Err: fmt.Errorf("only linked, with no version to restore: run `gopkg add %s@<version>` first, or `gopkg remove %s`", module, module)}
+	}
+
+	tx, err := p.Begin("unlink")
+	if err != nil {
+		return nil, err
+	}
+	delete(cfg.Links, module)
+	var result *InstallResult
+	if err = p.SaveManifest(cfg); err == nil {
+		result, err = p.install(ctx, InstallOptions{Only: []string{module}})
+	}
+	return result, p.finishInstall(ctx, tx, result, err)
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLinkUnlink(t *testing.T) {
+	proxy := newTestProxy(t, testModule{Path: "example.com/lib", Version: "v1.0.0"})
+	p := newTestProject(t, proxy, `name = "app"
+
+[dependencies]
+"example.com/lib" = "v1.0.0"
+`)
+	ctx := context.Background()
+	if _, err := p.Install(ctx, InstallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	local := filepath.Join(p.Layout.Root, "lib")
+	writeFile(t, filepath.Join(local, "go.mod"), "module example.com/lib\n")
+	gomod := filepath.Join(p.Layout.Root, "go.mod")
+
+	if _, err := p.Link(ctx, "example.com/lib", "./lib"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Links["example.com/lib"] != "./lib" {
+		t.Errorf("links = %v", cfg.Links)
+	}
+	if got := readFile(t, gomod); !strings.Contains(got, "example.com/lib => ./lib") {
+		t.Errorf("go.mod after link:\n%s", got)
+	}
+
+	result, err := p.Unlink(ctx, "example.com/lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Modules) != 1 || result.Modules[0].Resolved != "v1.0.0" {
+		t.Errorf("unlink result = %+v", result.Modules)
+	}
+	if got := readFile(t, gomod); !strings.Contains(got, "example.com/lib => ./gopkg_modules/example.com/lib@v1.0.0") {
+		t.Errorf("go.mod after unlink:\n%s", got)
+	}
+	if cfg, _ := p.LoadManifest(); len(cfg.Links) != 0 {
+		t.Errorf("links after unlink = %v", cfg.Links)
+	}
+
+	if _, err := p.Unlink(ctx, "example.com/lib"); err == nil {
+		t.Error("unlinking a module that is not linked succeeded")
+	}
+}
+
+func TestLinkUndeclared(t *testing.T) {
+	p := newTestProject(t, newTestProxy(t), "name = \"app\"\n\n[dependencies]\n")
+	writeFile(t, filepath.Join(p.Layout.Root, "other", "go.mod"), "module example.com/other\n")
+	gomod := filepath.Join(p.Layout.Root, "go.mod")
+	ctx := context.Background()
+
+	result, err := p.Link(ctx, "example.com/other", "./other")
+	if err == nil {
+		err = errors.Join(result.Errors()...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dependencies["example.com/other"] != localPseudoVersion || cfg.Links["example.com/other"] != "./other" {
+		t.Errorf("dependencies = %v, links = %v", cfg.Dependencies, cfg.Links)
+	}
+	if got := readFile(t, gomod); !strings.Contains(got, "example.com/other "+localPseudoVersion) || !strings.Contains(got, "example.com/other => ./other") {
+		t.Errorf("go.mod after link:\n%s", got)
+	}
+
+	toml := readFile(t, p.Layout.TomlPath())
+	_, err = p.Unlink(ctx, "example.com/other")
+	var me *ModuleError
+	if !errors.As(err, &me) || me.Kind != ErrConflict {
+		t.Fatalf("Unlink = %v, want a conflict error", err)
+	}
+	if got := readFile(t, p.Layout.TomlPath()); got != toml {
+		t.Errorf("gopkg.toml changed:\n%s", got)
+	}
+
+	if err := p.Remove("example.com/other"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := p.LoadManifest(); len(cfg.Dependencies) != 0 || len(cfg.Links) != 0 {
+		t.Errorf("after remove: dependencies = %v, links = %v", cfg.Dependencies, cfg.Links)
+	}
+	if got := readFile(t, gomod); strings.Contains(got, "example.com/other") {
+		t.Errorf("go.mod after remove:\n%s", got)
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// LockChange is a module whose locked version differs between two lockfiles.
+type LockChange struct {
+	Module string     `json:"module" yaml:"module"`
+	Before string     `json:"before,omitempty" yaml:"before,omitempty"`
+	After  string     `json:"after,omitempty" yaml:"after,omitempty"`
+	Kind   ChangeKind `json:"kind" yaml:"kind"`
+	// Severity is the size of the semver jump for upgrades and downgrades,
+	// and "none" for everything else.
+	Severity UpdateKind `json:"severity" yaml:"severity"`
+	// SumChanged is set when the same version now has a different checksum.
+	SumChanged bool `json:"sum_changed,omitempty" yaml:"sum_changed,omitempty"`
+	Indirect   bool `json:"indirect,omitempty" yaml:"indirect,omitempty"`
+}
+
+// Severity reports whether moving between two versions crosses a major,
+// minor or patch boundary.
+func Severity(from, to string) UpdateKind {
+	switch {
+	case !semver.IsValid(from) || !semver.IsValid(to) || semver.Compare(from, to) == 0:
+		return UpdateNone
+	case semver.Major(from) != semver.Major(to):
+		return UpdateMajor
+	case semver.MajorMinor(from) != semver.MajorMinor(to):
+		return UpdateMinor
+	}
+	return UpdatePatch
+}
+
+// DiffLocks compares the resolved versions of two lockfiles, sorted by
+// module path.
+func DiffLocks(before, after []LockEntry) []LockChange {
+	old, cur := lockEntries(before), lockEntries(after)
+	names := map[string]string{}
+	for name := range old {
+		names[name] = ""
+	}
+	for name := range cur {
+		names[name] = ""
+	}
+
+	changes := []LockChange{}
+	for _, name := range sortedKeys(names) {
+		b, hadBefore := old[name]
+		a, hasAfter := cur[name]
+		c := LockChange{Module: name, Before: b.Resolved, After: a.Resolved, Severity: UpdateNone, Indirect: a.Indirect}
+		if !hasAfter {
+			c.Indirect = b.Indirect
+		}
+		switch {
+		case !hadBefore:
+			c.Kind = ChangeAdded
+		case !hasAfter:
+			c.Kind = ChangeRemoved
+		case b.Resolved == a.Resolved:
+			if b.Sum == a.Sum || b.Sum == "" || a.Sum == "" {
+				continue
+			}
+			c.Kind, c.SumChanged = ChangeChanged, true
+		default:
+			c.Kind = VersionChange{Module: name, Before: b.Resolved, After: a.Resolved}.Kind()
+			c.Severity = Severity(b.Resolved, a.Resolved)
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+func lockEntries(entries []LockEntry) map[string]LockEntry {
+	m := make(map[string]LockEntry, len(entries))
+	for _, e := range entries {
+		m[e.Name] = e
+	}
+	return m
+}
+
+// LockAtRevision reads the lockfile at lockPath as it was in the given git
+// revision. A revision without the file yields an empty lock.
+func LockAtRevision(lockPath, ref string) ([]LockEntry, error) {
+	dir, name := filepath.Dir(lockPath), filepath.Base(lockPath)
+	if _, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, NewError(ErrNotFound, "unknown git revision %q", ref)
+	}
+	spec := ref + ":./" + name
+	if _, err := git(dir, "cat-file", "-e", spec); err != nil {
+		return []LockEntry{}, nil
+	}
+	data, err := git(dir, "show", spec)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := DecodeLock(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s in %s: %w", name, ref, err)
+	}
+	return entries, nil
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// LockMerge is the result of MergeLocks.
+type LockMerge struct {
+	Lock LockFile
+	// Notes are decisions worth surfacing, such as a removal losing to a
+	// change on the other side.
+	Notes []string
+	// Conflicts are modules both sides changed differently. The merged lock
+	// keeps our entry for them.
+	Conflicts []string
+}
+
+// MergeLocks does a three-way merge of lockfiles. A module changed on one
+// side only takes that side, and a removal loses to a change. A module both
+// sides changed differently is a conflict. The manifest hash is merged the
+// same way; when both sides changed it, it is left empty.
+func MergeLocks(base, ours, theirs *LockFile) LockMerge {
+	b, o, t := lockEntries(base.Dependencies), lockEntries(ours.Dependencies), lockEntries(theirs.Dependencies)
+	names := map[string]string{}
+	for _, m := range []map[string]LockEntry{b, o, t} {
+		for name := range m {
+			names[name] = ""
+		}
+	}
+
+	var res LockMerge
+	keep := func(e LockEntry) { res.Lock.Dependencies = append(res.Lock.Dependencies, e) }
+	for _, name := range sortedKeys(names) {
+		be, inBase := b[name]
+		oe, inOurs := o[name]
+		te, inTheirs := t[name]
+
+		switch {
+		case inOurs == inTheirs && sameLock(oe, te):
+			if inOurs {
+				keep(oe)
+			}
+		case inOurs == inBase && sameLock(oe, be):
+			if inTheirs {
+				keep(te)
+			}
+		case inTheirs == inBase && sameLock(te, be):
+			if inOurs {
+				keep(oe)
+			}
+		case !inOurs:
+			keep(te)
+			res.Notes = append(res.Notes, fmt.Sprintf("%s: removed on our side but changed on theirs, kept %s", name, te.Resolved))
+		case !inTheirs:
+			keep(oe)
+			res.Notes = append(res.Notes, fmt.Sprintf("%s: removed on their side but changed on ours, kept %s", name, oe.Resolved))
+		default:
+			keep(oe)
+			res.Conflicts = append(res.Conflicts, fmt.Sprintf("%s: ours %s, theirs %s", name, describeLock(oe), describeLock(te)))
+		}
+	}
+	sort.Slice(res.Lock.Dependencies, func(i, j int) bool { return res.Lock.Dependencies[i].Name < res.Lock.Dependencies[j].Name })
+
+	switch {
+	case ours.ManifestHash == theirs.ManifestHash || theirs.ManifestHash == base.ManifestHash:
+		res.Lock.ManifestHash = ours.ManifestHash
+	case ours.ManifestHash == base.ManifestHash:
+		res.Lock.ManifestHash = theirs.ManifestHash
+	default:
+		// The hash covers gopkg.toml, or at a workspace root every member's,
+		// which the lock alone cannot rebuild. An empty hash is never stale,
+		// and the next install records the right one.
+		res.Notes = append(res.Notes, "manifest_hash: gopkg.toml changed on both sides, cleared until the next `gopkg install`")
+	}
+	return res
+}
+
+// describeLock renders an entry for a conflict report, with the declared
+// version when it is not the resolved one.
+func describeLock(e LockEntry) string {
+	if e.Version != "" && e.Version != e.Resolved {
+		return e.Resolved + " (" + e.Version + ")"
+	}
+	return e.Resolved
+}
+
+// sameLock compares the fields that pin a module; requires edges follow
+// from the resolved version.
+func sameLock(a, b LockEntry) bool {
+	return a.Version == b.Version && a.Resolved == b.Resolved && a.Sum == b.Sum && a.Source == b.Source
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func lockOf(hash string, entries ...LockEntry) *LockFile {
+	return &LockFile{ManifestHash: hash, Dependencies: entries}
+}
+
+func entry(name, version string) LockEntry {
+	return LockEntry{Name: name, Version: version, Resolved: version}
+}
+
+func TestMergeLocks(t *testing.T) {
+	a1, a2, a3 := entry("example.com/a", "v1.0.0"), entry("example.com/a", "v1.1.0"), entry("example.com/a", "v1.2.0")
+	b1, b2 := entry("example.com/b", "v1.0.0"), entry("example.com/b", "v2.0.0")
+	c1 := entry("example.com/c", "v0.1.0")
+
+	tests := []struct {
+		name               string
+		base, ours, theirs *LockFile
+		want               []LockEntry
+		notes, conflicts   int
+	}{
+		{"unchanged", lockOf("", a1), lockOf("", a1), lockOf("", a1), []LockEntry{a1}, 0, 0},
+		{"changed on ours", lockOf("", a1, b1), lockOf("", a2, b1), lockOf("", a1, b1), []LockEntry{a2, b1}, 0, 0},
+		{"changed on theirs", lockOf("", a1, b1), lockOf("", a1, b1), lockOf("", a1, b2), []LockEntry{a1, b2}, 0, 0},
+		{"changed on both sides", lockOf("", a1, b1), lockOf("", a2, b1), lockOf("", a1, b2), []LockEntry{a2, b2}, 0, 0},
+		{"same change on both sides", lockOf("", a1), lockOf("", a2), lockOf("", a2), []LockEntry{a2}, 0, 0},
+		{"added on theirs", lockOf("", a1), lockOf("", a1), lockOf("", a1, c1), []LockEntry{a1, c1}, 0, 0},
+		{"removed on ours", lockOf("", a1, c1), lockOf("", a1), lockOf("", a1, c1), []LockEntry{a1}, 0, 0},
+		{"removal loses to a change", lockOf("", a1), lockOf(""), lockOf("", a2), []LockEntry{a2}, 1, 0},
+		{"conflict keeps ours", lockOf("", a1), lockOf("", a2), lockOf("", a3), []LockEntry{a2}, 0, 1},
+		{"added differently on both sides", lockOf(""), lockOf("", a1), lockOf("", a2), []LockEntry{a1}, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeLocks(tt.base, tt.ours, tt.theirs)
+			if !reflect.DeepEqual(got.Lock.Dependencies, tt.want) {
+				t.Errorf("entries = %v, want %v", got.Lock.Dependencies, tt.want)
+			}
+			if len(got.Notes) != tt.notes || len(got.Conflicts) != tt.conflicts {
+				t.Errorf("notes = %q, conflicts = %q, want %d and %d", got.Notes, got.Conflicts, tt.notes, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMergeLocksManifestHash(t *testing.T) {
+	a1, a2 := entry("example.com/a", "v1.0.0"), entry("example.com/a", "v1.1.0")
+	b1 := entry("example.com/b", "v1.0.0")
+
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		notes              int
+	}{
+		{"unchanged", "h0", "h0", "h0", "h0", 0},
+		{"changed on ours", "h0", "h1", "h0", "h1", 0},
+		{"changed on theirs", "h0", "h0", "h2", "h2", 0},
+		{"same on both sides", "h0", "h1", "h1", "h1", 0},
+		{"changed on both sides", "h0", "h1", "h2", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeLocks(lockOf(tt.base, a1), lockOf(tt.ours, a2), lockOf(tt.theirs, a1, b1))
+			if got.Lock.ManifestHash != tt.want || len(got.Notes) != tt.notes {
+				t.Errorf("manifest hash = %q, notes %q, want %q and %d notes", got.Lock.ManifestHash, got.Notes, tt.want, tt.notes)
+			}
+		})
+	}
+}
+
+// At a workspace root the hash covers every member's gopkg.toml, so a merge
+// where both branches changed it must not leave a hash install rejects.
+func TestMergeLocksWorkspace(t *testing.T) {
+	proxy := newTestProxy(t,
+		testModule{Path: "example.com/a", Version: "v1.0.0"},
+		testModule{Path: "example.com/b", Version: "v1.0.0"},
+	)
+	p := newTestProject(t, proxy, `name = "root"
+
+[dependencies]
+
+[workspace]
+members = ["svc/*"]
+`)
+	for _, svc := range []string{"one", "two"} {
+		dir := filepath.Join(p.Layout.Root, "svc", svc)
+		writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/"+svc+"\n\ngo 1.21\n")
+		writeFile(t, filepath.Join(dir, "gopkg.toml"), "name = \""+svc+"\"\n\n[dependencies]\n")
+	}
+	ctx := context.Background()
+	install := func(opts InstallOptions) {
+		t.Helper()
+		result, err := p.Install(ctx, opts)
+		if err == nil {
+			err = errors.Join(result.Errors()...)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	install(InstallOptions{})
+	base, err := ReadLockFileAt(p.Layout.LockPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each branch adds a dependency to a different member.
+	lockAfter := func(svc, module string) *LockFile {
+		t.Helper()
+		writeFile(t, filepath.Join(p.Layout.Root, "svc", svc, "gopkg.toml"), "name = \""+svc+"\"\n\n[dependencies]\n\""+module+"\" = \"v1.0.0\"\n")
+		install(InstallOptions{})
+		lock, err := ReadLockFileAt(p.Layout.LockPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lock
+	}
+	ours := lockAfter("one", "example.com/a")
+	writeFile(t, filepath.Join(p.Layout.Root, "svc", "one", "gopkg.toml"), "name = \"one\"\n\n[dependencies]\n")
+	theirs := lockAfter("two", "example.com/b")
+	writeFile(t, filepath.Join(p.Layout.Root, "svc", "one", "gopkg.toml"), "name = \"one\"\n\n[dependencies]\n\"example.com/a\" = \"v1.0.0\"\n")
+
+	merge := MergeLocks(base, ours, theirs)
+	if len(merge.Conflicts) != 0 {
+		t.Fatalf("conflicts: %q", merge.Conflicts)
+	}
+	if err := SaveLockFileAt(p.Layout.LockPath(), merge.Lock); err != nil {
+		t.Fatal(err)
+	}
+	if stale, err := p.LockStale(); err != nil || stale {
+		t.Fatalf("merged lock stale = %v, %v", stale, err)
+	}
+	install(InstallOptions{Frozen: true})
+
+	install(InstallOptions{})
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := p.manifestHash(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock, _ := ReadLockFileAt(p.Layout.LockPath()); lock.ManifestHash != want {
+		t.Errorf("manifest hash after install = %q, want %q", lock.ManifestHash, want)
+	}
+}
@@ -1,67 +1,174 @@
 package core
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
+// LockVersion is the lockfile format written by this version of gopkg.
+// Version 1 files had no version field and are migrated when read.
+const LockVersion = 2
+
 type LockEntry struct {
-	Name          string `toml:"name"`
-	Version       string `toml:"version"`
-	Resolved      string `toml:"resolved"`
-	Hash          string `toml:"hash"`
-	ResolvedTime  string `toml:"resolved_time"`
-	InstalledTime string `toml:"installed_time"`
-	Source        string `toml:"source"`
+	Name         string `toml:"name"`
+	Version      string `toml:"version,omitempty"`
+	Resolved     string `toml:"resolved"`
+	Hash         string `toml:"hash,omitempty"`
+	Sum          string `toml:"sum,omitempty"`
+	ResolvedTime string `toml:"resolved_time,omitempty"`
+	Source       string `toml:"source,omitempty"`
+	// Indirect entries are transitive dependencies, recorded at the version
+	// minimal version selection picks; they are not installed by gopkg.
+	Indirect bool `toml:"indirect,omitempty"`
+	// Requires lists the module@version requirements from the module's go.mod.
+	Requires []string `toml:"requires,omitempty"`
 }
 
 type LockFile struct {
+	Version int `toml:"version"`
+	// ManifestHash is a hash of gopkg.toml's dependencies when the lock was
+	// written; a different hash means the lock is stale.
+	ManifestHash string      `toml:"manifest_hash,omitempty"`
 	Dependencies []LockEntry `toml:"dependencies"`
 }
 
 func GetLockFilePath(global bool) string {
-	if global {
-		return filepath.Join(os.Getenv("HOME"), ".gopkg", "gopkg.lock")
-	}
-	return "gopkg.lock"
+	return DefaultLayout(global).LockPath()
 }
 
 func WriteLockFile(entries []LockEntry, global bool) error {
-	lock := LockFile{Dependencies: entries}
-	lockPath := GetLockFilePath(global)
+	return WriteLockFileAt(GetLockFilePath(global), entries)
+}
+
+func LoadLockFile(global bool) ([]LockEntry, error) {
+	return LoadLockFileAt(GetLockFilePath(global))
+}
+
+func WriteLockFileAt(lockPath string, entries []LockEntry) error {
+	return SaveLockFileAt(lockPath, LockFile{Dependencies: entries})
+}
+
+// SaveLockFileAt writes lock in the current format with entries sorted by
+// module path, so the same dependencies always produce the same file.
+func SaveLockFileAt(lockPath string, lock LockFile) error {
+	lock.Version = LockVersion
+	lock.Dependencies = append([]LockEntry{}, lock.Dependencies...)
+	sort.Slice(lock.Dependencies, func(i, j int) bool { return lock.Dependencies[i].Name < lock.Dependencies[j].Name })
 
 	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
 		return fmt.Errorf("failed to create lockfile directory: %w", err)
 	}
 
-	f, err := os.Create(lockPath)
-	if err != nil {
-		return fmt.Errorf("failed to create lockfile: %w", err)
-	}
-	defer f.Close()
-
-	if err := toml.NewEncoder(f).Encode(lock); err != nil {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(lock); err != nil {
 		return fmt.Errorf("failed to encode lockfile: %w", err)
 	}
-
+	if err := os.WriteFile(lockPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
 	return nil
 }
 
-func LoadLockFile(global bool) ([]LockEntry, error) {
-	var lock LockFile
-	lockPath := GetLockFilePath(global)
+func LoadLockFileAt(lockPath string) ([]LockEntry, error) {
+	lock, err := ReadLockFileAt(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	return lock.Dependencies, nil
+}
 
-	if _, err := os.Stat(lockPath); os.IsNotExist(err) {
-		return []LockEntry{}, nil
+// ReadLockFileAt reads a lockfile, returning an empty current-format lock
+// when the file does not exist.
+func ReadLockFileAt(lockPath string) (*LockFile, error) {
+	data, err := os.ReadFile(lockPath)
+	if os.IsNotExist(err) {
+		return &LockFile{Version: LockVersion, Dependencies: []LockEntry{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
 	}
+	return DecodeLockFile(data)
+}
 
-	_, err := toml.DecodeFile(lockPath, &lock)
+// DecodeLock parses lockfile contents, e.g. read from another git revision.
+func DecodeLock(data []byte) ([]LockEntry, error) {
+	lock, err := DecodeLockFile(data)
 	if err != nil {
+		return nil, err
+	}
+	return lock.Dependencies, nil
+}
+
+// DecodeLockFile parses lockfile contents and migrates older formats to the
+// current one. Version 1 files only differ in their volatile installed_time
+// stamps, which are dropped, and in having no manifest hash.
+func DecodeLockFile(data []byte) (*LockFile, error) {
+	var lock LockFile
+	if _, err := toml.Decode(string(data), &lock); err != nil {
 		return nil, fmt.Errorf("failed to decode lockfile: %w", err)
 	}
+	switch {
+	case lock.Version > LockVersion:
+		return nil, NewError(ErrConflict, "gopkg.lock uses format version %d, this gopkg only understands up to %d; upgrade gopkg", lock.Version, LockVersion)
+	case lock.Version < LockVersion:
+		lock.Version = LockVersion
+		lock.ManifestHash = ""
+	}
+	if lock.Dependencies == nil {
+		lock.Dependencies = []LockEntry{}
+	}
+	sort.Slice(lock.Dependencies, func(i, j int) bool { return lock.Dependencies[i].Name < lock.Dependencies[j].Name })
+	return &lock, nil
+}
 
-	return lock.Dependencies, nil
+// pruneIndirect drops indirect entries that no direct entry requires any
+// more, following the recorded requires edges.
+func pruneIndirect(entries []LockEntry) []LockEntry {
+	byName := lockEntries(entries)
+	keep := map[string]bool{}
+	var queue []LockEntry
+	for _, e := range entries {
+		if !e.Indirect {
+			queue = append(queue, e)
+		}
+	}
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		if keep[e.Name] {
+			continue
+		}
+		keep[e.Name] = true
+		for _, req := range e.Requires {
+			path, _, _ := strings.Cut(req, "@")
+			if next, ok := byName[path]; ok && !keep[path] {
+				queue = append(queue, next)
+			}
+		}
+	}
+	pruned := make([]LockEntry, 0, len(keep))
+	for _, e := range entries {
+		if keep[e.Name] {
+			pruned = append(pruned, e)
+		}
+	}
+	return pruned
+}
+
+// ManifestHash hashes the declared dependencies so a lock can tell whether
+// gopkg.toml changed since it was written.
+func ManifestHash(deps map[string]string) string {
+	h := sha256.New()
+	for _, m := range sortedKeys(deps) {
+		fmt.Fprintf(h, "%s %s\n", m, deps[m])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
 }
@@ -1,9 +1,9 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"time"
 )
 
@@ -23,20 +23,25 @@ type ModuleMetadata struct {
 	Hash    string    `json:"Hash,omitempty"`
 }
 
-func FetchModuleMetadata(module, version string) (*ModuleMetadata, error) {
-	url := fmt.Sprintf("https://proxy.golang.org/%s/@v/%s.info", module, version)
+func FetchModuleMetadata(ctx context.Context, module, version string) (*ModuleMetadata, error) {
+	return DefaultProxy().Metadata(ctx, module, version)
+}
+
+func (p *Proxy) Metadata(ctx context.Context, module, version string) (*ModuleMetadata, error) {
+	url := p.moduleURL(module, "@v/"+version+".info")
 	if version == "latest" {
-		url = fmt.Sprintf("https://proxy.golang.org/%s/@latest", module)
+		url = p.moduleURL(module, "@latest")
 	}
 
-	resp, err := http.Get(url)
+	report(ctx, Event{Kind: EventResolveStart, Module: module, Version: version})
+	resp, err := p.get(ctx, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch metadata: %w", err)
+		return nil, networkError(module, version, fmt.Errorf("failed to fetch metadata: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("failed to resolve version %q for %s: status %d", version, module, resp.StatusCode)
+		return nil, statusError(module, version, "metadata", resp.StatusCode)
 	}
 
 	var data proxyMeta
@@ -45,6 +50,7 @@ func FetchModuleMetadata(module, version string) (*ModuleMetadata, error) {
 		return nil, fmt.Errorf("failed to parse metadata: %w", err)
 	}
 
+	report(ctx, Event{Kind: EventResolveDone, Module: module, Version: data.Version})
 	return &ModuleMetadata{
 		Version: data.Version,
 		Time:    data.Time,
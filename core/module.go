@@ -9,6 +9,10 @@ import (
 type GopkgToml struct {
 	Name         string            `toml:"name"`
 	Dependencies map[string]string `toml:"dependencies"`
+	Links        map[string]string `toml:"links,omitempty"`
+	Settings     *Settings         `toml:"settings,omitempty"`
+	Policy       *Policy           `toml:"policy,omitempty"`
+	Workspace    *WorkspaceConfig  `toml:"workspace,omitempty"`
 }
 
 func LoadToml(path string) (*GopkgToml, error) {
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+type UpdateKind string
+
+const (
+	UpdateNone  UpdateKind = "none"
+	UpdatePatch UpdateKind = "patch"
+	UpdateMinor UpdateKind = "minor"
+	UpdateMajor UpdateKind = "major"
+)
+
+var updateRank = map[UpdateKind]int{UpdateMajor: 0, UpdateMinor: 1, UpdatePatch: 2, UpdateNone: 3}
+
+type OutdatedResult struct {
+	Module      string
+	Declared    string
+	Current     string
+	Wanted      string
+	LatestPatch string
+	LatestMinor string
+	LatestMajor string
+	// MajorPath is the module path of LatestMajor when it lives under a
+	// different /vN suffix.
+	MajorPath string
+	Update    UpdateKind
+	Err       error
+}
+
+// Outdated compares each dependency's locked version with what the proxy
+// offers: the best match for its constraint, the newest patch and minor
+// release of the current major, and the newest release of any later major
+// version path.
+func (p *Project) Outdated(ctx context.Context) ([]OutdatedResult, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	lockMap := p.lockMap()
+
+	modules := sortedKeys(cfg.Dependencies)
+	results := make([]OutdatedResult, len(modules))
+
+	jobs := p.config().Jobs()
+	if jobs <= 0 {
+		jobs = DefaultJobs
+	}
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, m := range modules {
+		results[i] = OutdatedResult{Module: m, Declared: cfg.Dependencies[m], Current: lockMap[m].Resolved, Update: UpdateNone}
+		if _, linked := cfg.Links[m]; linked {
+			continue
+		}
+		_, ignore, err := cfg.Policy.Rule(m, "")
+		if err != nil {
+			results[i].Err = &ModuleError{Module: m, Kind: ErrConflict, Err: err}
+			continue
+		}
+		wg.Add(1)
+		go func(r *OutdatedResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			r.Err = p.outdated(ctx, r, ignore)
+		}(&results[i])
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return updateRank[results[i].Update] < updateRank[results[j].Update]
+	})
+	return results, nil
+}
+
+func (p *Project) outdated(ctx context.Context, r *OutdatedResult, ignore []Constraint) error {
+	all, err := p.releases(ctx, r.Module)
+	if err != nil {
+		return err
+	}
+	versions := all[:0:0]
+	for _, v := range all {
+		if v == r.Current || allowedUpdate("", v, PolicyMajor, ignore) {
+			versions = append(versions, v)
+		}
+	}
+
+	if c, err := ParseConstraint(r.Declared); err == nil {
+		r.Wanted = c.Select(versions)
+	}
+
+	current := r.Current
+	if current == "" {
+		current = r.Wanted
+	}
+	for _, v := range versions {
+		if semver.Prerelease(v) != "" || semver.Compare(v, current) <= 0 {
+			continue
+		}
+		if semver.Major(v) != semver.Major(current) {
+			continue
+		}
+		if semver.MajorMinor(v) == semver.MajorMinor(current) {
+			r.LatestPatch = maxVersion(r.LatestPatch, v)
+		}
+		r.LatestMinor = maxVersion(r.LatestMinor, v)
+	}
+
+	majorPath, major, err := p.latestMajor(ctx, r.Module)
+	if err != nil {
+		return err
+	}
+	if major != "" {
+		r.MajorPath, r.LatestMajor = majorPath, major
+	} else {
+		// v0 and v1 share a path, so v1 counts as a new major for v0.x.
+		for _, v := range versions {
+			if semver.Prerelease(v) == "" && semver.Major(v) != semver.Major(current) && semver.Compare(v, current) > 0 {
+				r.LatestMajor = maxVersion(r.LatestMajor, v)
+			}
+		}
+	}
+
+	switch {
+	case current == "":
+	case r.LatestMajor != "":
+		r.Update = UpdateMajor
+	case r.LatestMinor != "" && semver.MajorMinor(r.LatestMinor) != semver.MajorMinor(current):
+		r.Update = UpdateMinor
+	case r.LatestPatch != "":
+		r.Update = UpdatePatch
+	}
+	return nil
+}
+
+// releases returns the tagged versions of a module, falling back to @latest
+// for modules that only have pseudo-versions.
+func (p *Project) releases(ctx context.Context, path string) ([]string, error) {
+	versions, err := p.Proxy.Versions(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		latest, err := p.Proxy.Latest(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		versions = []string{latest}
+	}
+	return versions, nil
+}
+
+// latestMajor probes /vN+1, /vN+2, ... until the proxy has no such module.
+func (p *Project) latestMajor(ctx context.Context, path string) (string, string, error) {
+	prefix, pathMajor, ok := module.SplitPathVersion(path)
+	if !ok {
+		return "", "", nil
+	}
+	n := 1
+	if pathMajor != "" {
+		fmt.Sscanf(strings.TrimLeft(pathMajor, "/."), "v%d", &n)
+	}
+
+	sep := "/"
+	if strings.HasPrefix(pathMajor, ".") || strings.HasPrefix(prefix, "gopkg.in/") {
+		sep = "."
+	}
+
+	var foundPath, found string
+	for {
+		n++
+		candidate := fmt.Sprintf("%s%sv%d", prefix, sep, n)
+		versions, err := p.releases(ctx, candidate)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return foundPath, found, nil
+			}
+			return "", "", err
+		}
+		best := ""
+		for _, v := range versions {
+			if semver.Prerelease(v) == "" {
+				best = maxVersion(best, v)
+			}
+		}
+		if best == "" {
+			return foundPath, found, nil
+		}
+		foundPath, found = candidate, best
+	}
+}
+
+func maxVersion(a, b string) string {
+	if semver.Compare(b, a) > 0 {
+		return b
+	}
+	return a
+}
@@ -2,26 +2,30 @@ package core
 
 import (
 	"os"
-	"path/filepath"
 )
 
-func GetGlobalModulePath(module string) string {
-	return filepath.Join(os.Getenv("HOME"), ".gopkg", "modules", module)
+func GetGlobalModulePath(module, version string) string {
+	return DefaultLayout(true).ModuleDir(module, version)
 }
 
 func GetGlobalModulesPath() string {
-	return filepath.Join(os.Getenv("HOME"), ".gopkg", "modules")
+	return DefaultLayout(true).ModulesDir()
 }
 
 func GetCacheDir() string {
-	return filepath.Join(os.Getenv("HOME"), ".gopkg", "cache")
+	return DefaultLayout(false).Cache
+}
+
+func GetLinkRegistryPath() string {
+	return DefaultLayout(false).LinkRegistryPath()
+}
+
+func GetConfigPath() string {
+	return DefaultLayout(false).ConfigPath()
 }
 
 func GetTomlPath(global bool) string {
-	if global {
-		return filepath.Join(os.Getenv("HOME"), ".gopkg", "gopkg.toml")
-	}
-	return "gopkg.toml"
+	return DefaultLayout(global).TomlPath()
 }
 
 func GetCurrentDir() string {
@@ -33,5 +37,5 @@ func GetCurrentDir() string {
 }
 
 func GetVendorPath() string {
-	return "gopkg_modules"
+	return DefaultLayout(false).ModulesDir()
 }
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+
+	"golang.org/x/mod/semver"
+)
+
+// UpdatePolicy limits how far `gopkg update` may move a dependency.
+type UpdatePolicy string
+
+const (
+	PolicyMajor UpdatePolicy = "major"
+	PolicyMinor UpdatePolicy = "minor"
+	PolicyPatch UpdatePolicy = "patch"
+	PolicyNone  UpdatePolicy = "none"
+)
+
+var policyRank = map[UpdatePolicy]int{PolicyNone: 0, PolicyPatch: 1, PolicyMinor: 2, PolicyMajor: 3, "": 3}
+
+// Policy is the [policy] table of gopkg.toml:
+//
+//	[policy]
+//	update = "minor"
+//
+//	allowed_licenses = ["MIT", "Apache-2.0", "BSD-3-Clause"]
+//	denied_licenses = ["AGPL-3.0"]
+//
+//	[policy.dependencies."github.com/foo/bar"]
+//	update = "patch"
+//	ignore = ["v1.4.2", ">=v1.5.0 <v1.6.0"]
+type Policy struct {
+	Update UpdatePolicy `toml:"update,omitempty"`
+	// AllowedLicenses and DeniedLicenses are SPDX identifiers that install
+	// checks newly installed modules against.
+	AllowedLicenses []string                    `toml:"allowed_licenses,omitempty"`
+	DeniedLicenses  []string                    `toml:"denied_licenses,omitempty"`
+	Dependencies    map[string]DependencyPolicy `toml:"dependencies,omitempty"`
+}
+
+type DependencyPolicy struct {
+	Update UpdatePolicy `toml:"update,omitempty"`
+	Ignore []string     `toml:"ignore,omitempty"`
+}
+
+func ParseUpdatePolicy(s string) (UpdatePolicy, error) {
+	p := UpdatePolicy(s)
+	if _, ok := policyRank[p]; !ok || s == "" {
+		return "", fmt.Errorf("invalid update policy %q (use major, minor, patch or none)", s)
+	}
+	return p, nil
+}
+
+// stricter returns the more restrictive of two policies; empty means major.
+func stricter(a, b UpdatePolicy) UpdatePolicy {
+	if policyRank[b] < policyRank[a] {
+		a = b
+	}
+	if a == "" {
+		return PolicyMajor
+	}
+	return a
+}
+
+// Rule returns the effective update policy and ignore list for a module,
+// with limit (from --patch/--minor) applied on top.
+func (pol *Policy) Rule(module string, limit UpdatePolicy) (UpdatePolicy, []Constraint, error) {
+	if pol == nil {
+		return stricter(limit, ""), nil, nil
+	}
+	policy := pol.Update
+	dep, ok := pol.Dependencies[module]
+	if ok && dep.Update != "" {
+		policy = dep.Update
+	}
+	if _, valid := policyRank[policy]; !valid {
+		return "", nil, fmt.Errorf("invalid update policy %q for %s", policy, module)
+	}
+
+	var ignore []Constraint
+	for _, s := range dep.Ignore {
+		c, err := ParseConstraint(s)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid ignore entry for %s: %w", module, err)
+		}
+		ignore = append(ignore, c)
+	}
+	return stricter(policy, limit), ignore, nil
+}
+
+// Allows reports whether moving from current to version is permitted.
+func (pol *Policy) Allows(module, current, version string, limit UpdatePolicy) bool {
+	policy, ignore, err := pol.Rule(module, limit)
+	return err == nil && allowedUpdate(current, version, policy, ignore)
+}
+
+func allowedUpdate(current, version string, policy UpdatePolicy, ignore []Constraint) bool {
+	for _, c := range ignore {
+		if c.Match(version) {
+			return false
+		}
+	}
+	if current == "" || !semver.IsValid(current) {
+		return policy != PolicyNone
+	}
+	switch policy {
+	case PolicyNone:
+		return false
+	case PolicyPatch:
+		return semver.MajorMinor(version) == semver.MajorMinor(current)
+	case PolicyMinor:
+		return semver.Major(version) == semver.Major(current)
+	}
+	return true
+}
+
+// PickUpdate returns the newest release above current that the policy and
+// ignore list allow, or "" when there is none.
+func PickUpdate(current string, versions []string, policy UpdatePolicy, ignore []Constraint) string {
+	best := ""
+	for _, v := range versions {
+		if semver.Prerelease(v) != "" || semver.Compare(v, current) <= 0 {
+			continue
+		}
+		if allowedUpdate(current, v, policy, ignore) {
+			best = maxVersion(best, v)
+		}
+	}
+	return best
+}
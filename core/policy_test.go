@@ -0,0 +1,166 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func mustConstraints(t *testing.T, specs ...string) []Constraint {
+	t.Helper()
+	var cs []Constraint
+	for _, s := range specs {
+		c, err := ParseConstraint(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+func TestAllowedUpdate(t *testing.T) {
+	ignore := mustConstraints(t, "v1.2.4", ">=v1.5.0 <v1.6.0")
+	tests := []struct {
+		current, version string
+		policy           UpdatePolicy
+		ignore           []Constraint
+		want             bool
+	}{
+		{"v1.2.3", "v2.0.0", PolicyMajor, nil, true},
+		{"v1.2.3", "v1.3.0", PolicyMinor, nil, true},
+		{"v1.2.3", "v2.0.0", PolicyMinor, nil, false},
+		{"v1.2.3", "v1.2.9", PolicyPatch, nil, true},
+		{"v1.2.3", "v1.3.0", PolicyPatch, nil, false},
+		{"v1.2.3", "v1.2.4", PolicyNone, nil, false},
+		{"v0.2.0", "v0.3.0", PolicyMinor, nil, true},
+		{"v0.2.0", "v1.0.0", PolicyMinor, nil, false},
+		{"v1.2.3", "v1.2.4", PolicyMajor, ignore, false},
+		{"v1.2.3", "v1.5.2", PolicyMajor, ignore, false},
+		{"v1.2.3", "v1.6.0", PolicyMajor, ignore, true},
+		{"", "v3.0.0", PolicyPatch, nil, true},
+		{"", "v3.0.0", PolicyNone, nil, false},
+		{"master", "v1.0.0", PolicyMinor, nil, true},
+	}
+	for _, tt := range tests {
+		if got := allowedUpdate(tt.current, tt.version, tt.policy, tt.ignore); got != tt.want {
+			t.Errorf("allowedUpdate(%q, %q, %s) = %v, want %v", tt.current, tt.version, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestPickUpdate(t *testing.T) {
+	versions := []string{"v1.2.3", "v1.2.4", "v1.2.5", "v1.3.0", "v1.4.0-rc.1", "v2.0.0", "v1.1.0"}
+	tests := []struct {
+		current string
+		policy  UpdatePolicy
+		ignore  []Constraint
+		want    string
+	}{
+		{"v1.2.3", PolicyMajor, nil, "v2.0.0"},
+		{"v1.2.3", PolicyMinor, nil, "v1.3.0"},
+		{"v1.2.3", PolicyPatch, nil, "v1.2.5"},
+		{"v1.2.3", PolicyPatch, mustConstraints(t, "v1.2.5"), "v1.2.4"},
+		{"v1.2.3", PolicyNone, nil, ""},
+		{"v2.0.0", PolicyMajor, nil, ""},
+		{"v1.3.0", PolicyPatch, nil, ""},
+		{"", PolicyMinor, nil, "v2.0.0"},
+	}
+	for _, tt := range tests {
+		if got := PickUpdate(tt.current, versions, tt.policy, tt.ignore); got != tt.want {
+			t.Errorf("PickUpdate(%q, %s) = %q, want %q", tt.current, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyRule(t *testing.T) {
+	pol := &Policy{
+		Update: PolicyMinor,
+		Dependencies: map[string]DependencyPolicy{
+			"example.com/pinned": {Update: PolicyNone},
+			"example.com/loose":  {Update: PolicyMajor, Ignore: []string{"v2.0.0"}},
+			"example.com/bad":    {Ignore: []string{">=x"}},
+		},
+	}
+	tests := []struct {
+		policy  *Policy
+		module  string
+		limit   UpdatePolicy
+		want    UpdatePolicy
+		ignored int
+		wantErr bool
+	}{
+		{nil, "example.com/any", "", PolicyMajor, 0, false},
+		{nil, "example.com/any", PolicyPatch, PolicyPatch, 0, false},
+		{pol, "example.com/any", "", PolicyMinor, 0, false},
+		{pol, "example.com/any", PolicyMajor, PolicyMinor, 0, false},
+		{pol, "example.com/any", PolicyPatch, PolicyPatch, 0, false},
+		{pol, "example.com/pinned", PolicyMajor, PolicyNone, 0, false},
+		{pol, "example.com/loose", "", PolicyMajor, 1, false},
+		{pol, "example.com/bad", "", "", 0, true},
+		{&Policy{Update: "sometimes"}, "example.com/any", "", "", 0, true},
+	}
+	for _, tt := range tests {
+		got, ignore, err := tt.policy.Rule(tt.module, tt.limit)
+		if (err != nil) != tt.wantErr || got != tt.want || len(ignore) != tt.ignored {
+			t.Errorf("Rule(%s, %q) = %s, %d ignored, %v; want %s, %d, error %v",
+				tt.module, tt.limit, got, len(ignore), err, tt.want, tt.ignored, tt.wantErr)
+		}
+	}
+}
+
+func TestPlanUpdatesKeepsConstraints(t *testing.T) {
+	proxy := newTestProxy(t,
+		testModule{Path: "example.com/caret", Version: "v0.2.0"},
+		testModule{Path: "example.com/caret", Version: "v0.2.1"},
+		testModule{Path: "example.com/caret", Version: "v0.3.0"},
+		testModule{Path: "example.com/floor", Version: "v0.1.0"},
+		testModule{Path: "example.com/floor", Version: "v0.1.3"},
+		testModule{Path: "example.com/floor", Version: "v0.2.0"},
+	)
+	p := newTestProject(t, proxy, `name = "app"
+
+[dependencies]
+"example.com/caret" = "^0.2.0"
+"example.com/floor" = ">=0.1.0"
+`)
+	ctx := context.Background()
+	if err := p.WriteLock([]LockEntry{{Name: "example.com/caret", Version: "^0.2.0", Resolved: "v0.2.0"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The caret range caps caret below v0.3.0; floor has no lock entry, so
+	// its floor is the current version and --patch stays on v0.1.x.
+	results, err := p.PlanUpdates(ctx, UpdateOptions{Limit: PolicyPatch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.Module] = r.Latest
+	}
+	if got["example.com/caret"] != "v0.2.1" || got["example.com/floor"] != "v0.1.3" {
+		t.Errorf("PlanUpdates(--patch) = %v", got)
+	}
+
+	if _, err := p.Update(ctx, UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dependencies["example.com/caret"] != "^0.2.0" || cfg.Dependencies["example.com/floor"] != ">=0.1.0" {
+		t.Errorf("update rewrote constraints: %v", cfg.Dependencies)
+	}
+	lock := p.lockMap()
+	if lock["example.com/caret"].Resolved != "v0.2.1" || lock["example.com/floor"].Resolved != "v0.2.0" {
+		t.Errorf("locked caret %s, floor %s, want v0.2.1 and v0.2.0", lock["example.com/caret"].Resolved, lock["example.com/floor"].Resolved)
+	}
+
+	if _, err := p.Update(ctx, UpdateOptions{Targets: map[string]string{"example.com/caret": "v0.3.0"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := p.LoadManifest(); cfg.Dependencies["example.com/caret"] != "v0.3.0" {
+		t.Errorf("a target outside the range kept %q", cfg.Dependencies["example.com/caret"])
+	}
+}
@@ -0,0 +1,456 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+type ModuleStatus string
+
+const (
+	StatusUpToDate        ModuleStatus = "up-to-date"
+	StatusOutdated        ModuleStatus = "outdated"
+	StatusAhead           ModuleStatus = "ahead"
+	StatusNotInstalled    ModuleStatus = "not-installed"
+	StatusLinked          ModuleStatus = "linked"
+	StatusLocked          ModuleStatus = "locked"
+	StatusInstalled       ModuleStatus = "installed"
+	StatusUpdateAvailable ModuleStatus = "update-available"
+	StatusUpdated         ModuleStatus = "updated"
+	StatusFailed          ModuleStatus = "failed"
+	StatusPinned          ModuleStatus = "pinned"
+)
+
+type ModuleResult struct {
+	Module   string
+	Declared string
+	Locked   string
+	Resolved string
+	Latest   string
+	Path     string
+	Status   ModuleStatus
+	Err      error
+}
+
+type Project struct {
+	Layout Layout
+	Proxy  *Proxy
+	// Config is the effective configuration for Layout. When nil it is
+	// loaded from Layout on first use.
+	Config *Config
+}
+
+// NewProject returns a project with the configuration of layout. A nil proxy
+// is built from the configured proxy setting.
+func NewProject(layout Layout, proxy *Proxy) *Project {
+	p := &Project{Layout: layout, Config: loadConfigOrDefaults(layout)}
+	if proxy == nil {
+		proxy = NewProxy(p.Config.Proxy(), layout.ConfigPath(), EnvHTTPOptions()...)
+	}
+	p.Proxy = proxy
+	return p
+}
+
+// LoadProject is NewProject with the configuration errors reported, and
+// overrides, such as command-line flags, applied on top of every layer.
+func LoadProject(layout Layout, overrides ...ConfigValue) (*Project, error) {
+	cfg, err := LoadConfig(layout)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		if err := cfg.Override(o.Key, o.Value, o.Source, o.Origin); err != nil {
+			return nil, err
+		}
+	}
+	proxy := NewProxy(cfg.Proxy(), layout.ConfigPath(), EnvHTTPOptions()...)
+	return &Project{Layout: layout, Proxy: proxy, Config: cfg}, nil
+}
+
+func DefaultProject(global bool) *Project {
+	return NewProject(DefaultLayout(global), nil)
+}
+
+func (p *Project) config() *Config {
+	if p.Config == nil {
+		p.Config = loadConfigOrDefaults(p.Layout)
+	}
+	return p.Config
+}
+
+func (p *Project) GoMod() GoMod {
+	return GoMod{Dir: p.Layout.Root}
+}
+
+func (p *Project) LoadManifest() (*GopkgToml, error) {
+	return LoadToml(p.Layout.TomlPath())
+}
+
+func (p *Project) NewManifest() *GopkgToml {
+	dir, err := filepath.Abs(filepath.Dir(p.Layout.TomlPath()))
+	if err != nil {
+		dir = p.Layout.Root
+	}
+	return &GopkgToml{
+		Name:         filepath.Base(dir),
+		Dependencies: map[string]string{},
+	}
+}
+
+// LoadOrCreateManifest returns the manifest, or a new empty one if the file
+// does not exist yet. The new manifest is not written.
+func (p *Project) LoadOrCreateManifest() (*GopkgToml, bool, error) {
+	path := p.Layout.TomlPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return p.NewManifest(), true, nil
+	}
+	cfg, err := LoadToml(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return cfg, false, nil
+}
+
+func (p *Project) SaveManifest(cfg *GopkgToml) error {
+	path := p.Layout.TomlPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := SaveToml(path, cfg); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
+
+func (p *Project) LoadLock() ([]LockEntry, error) {
+	return LoadLockFileAt(p.Layout.LockPath())
+}
+
+// WriteLock writes entries along with the hash of the current manifest.
+func (p *Project) WriteLock(entries []LockEntry) error {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		cfg = &GopkgToml{}
+	}
+	return p.writeLock(cfg, entries)
+}
+
+func (p *Project) writeLock(cfg *GopkgToml, entries []LockEntry) error {
+	lock := LockFile{Dependencies: entries}
+	if len(cfg.Dependencies) > 0 {
+		hash, err := p.manifestHash(cfg)
+		if err != nil {
+			return err
+		}
+		lock.ManifestHash = hash
+	}
+	return SaveLockFileAt(p.Layout.LockPath(), lock)
+}
+
+// LockStale reports whether gopkg.toml changed since gopkg.lock was written.
+// Locks without a manifest hash, such as migrated v1 files, are never stale.
+func (p *Project) LockStale() (bool, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return false, err
+	}
+	lock, err := ReadLockFileAt(p.Layout.LockPath())
+	if err != nil {
+		return false, err
+	}
+	if lock.ManifestHash == "" {
+		return false, nil
+	}
+	hash, err := p.manifestHash(cfg)
+	if err != nil {
+		return false, err
+	}
+	return lock.ManifestHash != hash, nil
+}
+
+func (p *Project) lockMap() map[string]LockEntry {
+	lockMap := map[string]LockEntry{}
+	if entries, err := p.LoadLock(); err == nil {
+		for _, entry := range entries {
+			lockMap[entry.Name] = entry
+		}
+	}
+	return lockMap
+}
+
+func (p *Project) Resolve(ctx context.Context, module, version string) (*ModuleMetadata, error) {
+	return p.Proxy.Metadata(ctx, module, version)
+}
+
+func (p *Project) Add(module, version string) error {
+	if err := ValidateConstraint(version); err != nil {
+		return &ModuleError{Module: module, Version: version, Kind: ErrInvalid, Err: err}
+	}
+	tx, err := p.Begin("add")
+	if err != nil {
+		return err
+	}
+	cfg, _, err := p.LoadOrCreateManifest()
+	if err == nil {
+		cfg.Dependencies[module] = version
+		err = p.SaveManifest(cfg)
+	}
+	_, txErr := tx.finish(context.Background(), err != nil, nil)
+	return errors.Join(err, txErr)
+}
+
+// Remove drops module from the manifest, the lockfile and go.mod, restoring
+// all of them if any step fails.
+func (p *Project) Remove(module string) (err error) {
+	path := p.Layout.TomlPath()
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	if _, exists := cfg.Dependencies[module]; !exists {
+		return &ModuleError{Module: module, Kind: ErrNotFound, Err: fmt.Errorf("not found in %s", path)}
+	}
+
+	tx, err := p.Begin("remove")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if _, txErr := tx.finish(context.Background(), err != nil, nil); txErr != nil {
+			err = errors.Join(err, txErr)
+		}
+	}()
+
+	delete(cfg.Dependencies, module)
+	delete(cfg.Links, module)
+	if err := p.SaveManifest(cfg); err != nil {
+		return err
+	}
+
+	// At a workspace root, a module a member still declares stays
+	// installed.
+	ws, err := p.workspace(cfg)
+	if err != nil {
+		return err
+	}
+	shared := ws != nil && ws.declares(module)
+
+	entries, _ := p.LoadLock()
+	var updated []LockEntry
+	for _, e := range entries {
+		if e.Name != module || shared {
+			updated = append(updated, e)
+		}
+	}
+	if err := p.WriteLock(pruneIndirect(updated)); err != nil {
+		return err
+	}
+
+	if !shared {
+		_ = p.dropReplace(module)
+	}
+	return nil
+}
+
+func (p *Project) Check(ctx context.Context) ([]ModuleResult, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	lockMap := p.lockMap()
+
+	results := []ModuleResult{}
+	for _, module := range sortedKeys(cfg.Dependencies) {
+		res := ModuleResult{Module: module, Declared: cfg.Dependencies[module]}
+
+		locked, found := lockMap[module]
+		if !found {
+			res.Status = StatusNotInstalled
+			results = append(results, res)
+			continue
+		}
+		res.Locked = locked.Resolved
+
+		latest, err := p.Proxy.Latest(ctx, module)
+		if err != nil {
+			res.Status, res.Err = StatusFailed, err
+			results = append(results, res)
+			continue
+		}
+		res.Latest = latest
+
+		if CompareVersions(latest, locked.Resolved) > 0 {
+			res.Status = StatusUpdateAvailable
+		} else {
+			res.Status = StatusUpToDate
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+type UpdateOptions struct {
+	// Targets maps modules to an explicit version or "latest". Explicit
+	// versions bypass the update policy. Empty means every dependency.
+	Targets map[string]string
+	// Limit caps every module's policy, e.g. PolicyPatch for --patch.
+	Limit UpdatePolicy
+	// command names the change in the history, "update" by default.
+	command string
+}
+
+// PlanUpdates decides the target version of each selected module without
+// changing anything. Rows that can move are StatusUpdateAvailable with the
+// target in Latest; modules whose policy is "none" are StatusPinned.
+func (p *Project) PlanUpdates(ctx context.Context, opts UpdateOptions) ([]ModuleResult, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	lockMap := p.lockMap()
+
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = map[string]string{}
+		for m := range cfg.Dependencies {
+			targets[m] = "latest"
+		}
+	}
+
+	results := []ModuleResult{}
+	for _, module := range sortedKeys(cfg.Dependencies) {
+		want, ok := targets[module]
+		if !ok {
+			continue
+		}
+		res := ModuleResult{Module: module, Declared: cfg.Dependencies[module], Locked: lockMap[module].Resolved}
+
+		if want != "latest" {
+			res.Latest, res.Status = want, StatusUpdateAvailable
+			results = append(results, res)
+			continue
+		}
+
+		policy, ignore, err := cfg.Policy.Rule(module, opts.Limit)
+		if err != nil {
+			res.Status, res.Err = StatusFailed, &ModuleError{Module: module, Kind: ErrConflict, Err: err}
+			results = append(results, res)
+			continue
+		}
+		if policy == PolicyNone {
+			res.Status = StatusPinned
+			results = append(results, res)
+			continue
+		}
+
+		// A range constraint bounds "latest", and its floor stands in for
+		// the current version while nothing is locked.
+		constraint, err := ParseConstraint(res.Declared)
+		if err != nil {
+			constraint = Constraint{}
+		}
+		current := res.Locked
+		if current == "" {
+			current = constraint.Floor()
+		}
+		versions, err := p.releases(ctx, module)
+		if err != nil {
+			res.Status, res.Err = StatusFailed, err
+			results = append(results, res)
+			continue
+		}
+		if constraint.IsRange() {
+			versions = slices.DeleteFunc(versions, func(v string) bool { return !constraint.Match(v) })
+		}
+
+		res.Status = StatusUpToDate
+		if target := PickUpdate(current, versions, policy, ignore); target != "" {
+			res.Latest, res.Status = target, StatusUpdateAvailable
+		} else {
+			res.Latest = current
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Update applies PlanUpdates to gopkg.toml and installs the result in one
+// pass.
+func (p *Project) Update(ctx context.Context, opts UpdateOptions) ([]ModuleResult, error) {
+	results, err := p.PlanUpdates(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return results, err
+	}
+	// Ranges and "latest" stay in gopkg.toml and only the lock moves, unless
+	// the target is outside of them. Exact versions are rewritten.
+	var changed []string
+	pins := map[string]string{}
+	for _, res := range results {
+		if res.Status != StatusUpdateAvailable {
+			continue
+		}
+		changed = append(changed, res.Module)
+		if c, err := ParseConstraint(res.Declared); err == nil && (c.IsRange() || c.IsLatest()) && c.Match(res.Latest) {
+			pins[res.Module] = res.Latest
+		} else {
+			cfg.Dependencies[res.Module] = res.Latest
+		}
+	}
+	if len(changed) == 0 {
+		return results, nil
+	}
+
+	command := opts.command
+	if command == "" {
+		command = "update"
+	}
+	tx, err := p.Begin(command)
+	if err != nil {
+		return results, err
+	}
+	var installed *InstallResult
+	if err = p.SaveManifest(cfg); err == nil {
+		installed, err = p.install(ctx, InstallOptions{Only: changed, pins: pins})
+	}
+	if err = p.finishInstall(ctx, tx, installed, err); err != nil {
+		return results, err
+	}
+
+	byModule := map[string]ModuleResult{}
+	for _, r := range installed.Modules {
+		byModule[r.Module] = r
+	}
+	for i := range results {
+		if results[i].Status != StatusUpdateAvailable {
+			continue
+		}
+		r := byModule[results[i].Module]
+		switch {
+		case r.Err != nil:
+			results[i].Status, results[i].Err = StatusFailed, r.Err
+		case !installed.RolledBack:
+			results[i].Status, results[i].Resolved = StatusUpdated, r.Resolved
+		}
+	}
+	return results, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
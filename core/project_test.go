@@ -0,0 +1,30 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddInvalidConstraint(t *testing.T) {
+	p := newTestProject(t, newTestProxy(t), "name = \"app\"\n\n[dependencies]\n")
+	toml := readFile(t, p.Layout.TomlPath())
+
+	err := p.Add("example.com/lib", ">=1.0 <")
+	if !errors.Is(err, ErrInvalid) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Add = %v, want an invalid-argument error", err)
+	}
+	if got := readFile(t, p.Layout.TomlPath()); got != toml {
+		t.Errorf("gopkg.toml changed:\n%s", got)
+	}
+
+	if err := p.Add("example.com/lib", "^1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dependencies["example.com/lib"] != "^1.2.0" {
+		t.Errorf("dependencies = %v", cfg.Dependencies)
+	}
+}
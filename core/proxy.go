@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pageton/gopkg/core/httpclient"
+)
+
+const defaultProxy = "https://proxy.golang.org"
+
+// ProxyURL is the proxy configured for the current directory.
+func ProxyURL() string {
+	return loadConfigOrDefaults(DefaultLayout(false)).Proxy()
+}
+
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type Proxy struct {
+	URL        string
+	ConfigPath string
+	client     *httpclient.Client
+}
+
+func NewProxy(rawURL, configPath string, opts ...httpclient.Option) *Proxy {
+	p := &Proxy{URL: strings.TrimSuffix(rawURL, "/"), ConfigPath: configPath}
+	opts = append([]httpclient.Option{httpclient.WithRequestHook(p.authorize)}, opts...)
+	p.client = httpclient.New(opts...)
+	return p
+}
+
+var DefaultProxy = sync.OnceValue(func() *Proxy {
+	return NewProxy(ProxyURL(), GetConfigPath(), EnvHTTPOptions()...)
+})
+
+func EnvHTTPOptions() []httpclient.Option {
+	var opts []httpclient.Option
+	if d, err := time.ParseDuration(os.Getenv("GOPKG_HTTP_TIMEOUT")); err == nil {
+		opts = append(opts, httpclient.WithConnectTimeout(d), httpclient.WithHeaderTimeout(d))
+	}
+	if n, err := strconv.Atoi(os.Getenv("GOPKG_HTTP_RETRIES")); err == nil {
+		opts = append(opts, httpclient.WithRetries(n))
+	}
+	return opts
+}
+
+func ProxySource() string {
+	return DefaultProxy().Source()
+}
+
+func (p *Proxy) Source() string {
+	return RedactURL(p.URL)
+}
+
+func (p *Proxy) moduleURL(module, elem string) string {
+	return fmt.Sprintf("%s/%s/%s", p.URL, escapeModulePath(module), elem)
+}
+
+func (p *Proxy) get(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request for %s", RedactURL(rawURL))
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ue, ok := err.(*url.Error); ok {
+			ue.URL = RedactURL(ue.URL)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
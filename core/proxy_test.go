@@ -0,0 +1,144 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// testModule is one module version served by newTestProxy. Files are
+// relative to the module root; go.mod is generated when missing.
+type testModule struct {
+	Path    string
+	Version string
+	Files   map[string]string
+}
+
+// newTestProxy serves modules over the GOPROXY protocol.
+func newTestProxy(t *testing.T, modules ...testModule) *Proxy {
+	t.Helper()
+	byPath := map[string][]testModule{}
+	for _, m := range modules {
+		byPath[m.Path] = append(byPath[m.Path], m)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, elem, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/@")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		versions := byPath[path]
+		if elem == "v/list" {
+			for _, m := range versions {
+				w.Write([]byte(m.Version + "\n"))
+			}
+			return
+		}
+		var mod *testModule
+		if elem == "latest" {
+			for i := range versions {
+				if mod == nil || semver.Compare(versions[i].Version, mod.Version) > 0 {
+					mod = &versions[i]
+				}
+			}
+		} else {
+			base := strings.TrimPrefix(elem, "v/")
+			for i := range versions {
+				if strings.HasPrefix(base, versions[i].Version+".") {
+					mod = &versions[i]
+				}
+			}
+		}
+		if mod == nil {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case elem == "latest" || strings.HasSuffix(elem, ".info"):
+			json.NewEncoder(w).Encode(proxyMeta{Version: mod.Version, Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+		case strings.HasSuffix(elem, ".mod"):
+			w.Write([]byte(mod.goMod()))
+		case strings.HasSuffix(elem, ".zip"):
+			w.Write(mod.zip(t))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewProxy(srv.URL, "")
+}
+
+func (m testModule) goMod() string {
+	if data, ok := m.Files["go.mod"]; ok {
+		return data
+	}
+	return "module " + m.Path + "\n\ngo 1.21\n"
+}
+
+func (m testModule) zip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{"go.mod": m.goMod()}
+	for name, data := range m.Files {
+		files[name] = data
+	}
+	for _, name := range sortedKeys(files) {
+		f, err := zw.Create(m.Path + "@" + m.Version + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(files[name]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newTestProject returns a project in a temporary directory with a go.mod,
+// the given gopkg.toml and everything gopkg keeps outside the project in
+// the same directory.
+func newTestProject(t *testing.T, proxy *Proxy, manifest string) *Project {
+	t.Helper()
+	dir := t.TempDir()
+	home := filepath.Join(dir, ".home")
+	layout := Layout{
+		Root:    dir,
+		Home:    home,
+		Cache:   filepath.Join(home, "cache"),
+		Config:  home,
+		Modules: filepath.Join(home, "modules"),
+	}
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/app\n\ngo 1.21\n")
+	writeFile(t, layout.TomlPath(), manifest)
+	return NewProject(layout, proxy)
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,281 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Reach says how much of a vulnerability the project's code can get to.
+type Reach string
+
+const (
+	// ReachCalled means a vulnerable symbol is on a call path from the
+	// project's entry points.
+	ReachCalled Reach = "reachable"
+	// ReachImported means a vulnerable package is built in, but none of its
+	// vulnerable symbols are called.
+	ReachImported Reach = "imported"
+	// ReachNone means no vulnerable package is part of the build.
+	ReachNone Reach = "unreachable"
+	// ReachUnknown means the packages the answer depends on failed to load
+	// or type-check.
+	ReachUnknown Reach = "unknown"
+)
+
+// Reachability type-checks the project and builds its call graph to find out
+// whether each vulnerability's symbols are actually called from the entry
+// points. Entries that list no symbols are matched by package, or by module
+// when they list no packages either. Packages that fail to load are left out
+// of the call graph, and vulnerabilities that could hide in them are unknown
+// unless a call path is found anyway.
+func (p *Project) Reachability(ctx context.Context, vulns []Vulnerability) error {
+	cfg := &packages.Config{
+		Context: ctx,
+		Dir:     p.Layout.Root,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps |
+			packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		report(ctx, Event{Kind: EventWarning, Message: fmt.Sprintf("failed to load packages, reachability is unknown: %v", err)})
+		for i := range vulns {
+			vulns[i].Reachable, vulns[i].Trace = ReachUnknown, nil
+		}
+		return nil
+	}
+	// built maps every package in the build to its module, empty for the
+	// standard library.
+	built, failed := map[string]string{}, map[string]bool{}
+	var firstErr string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Module != nil {
+			built[pkg.PkgPath] = pkg.Module.Path
+		} else {
+			built[pkg.PkgPath] = ""
+		}
+		if len(pkg.Errors) > 0 {
+			failed[pkg.PkgPath] = true
+			if firstErr == "" {
+				firstErr = pkg.Errors[0].Error()
+			}
+		}
+	})
+	// A project package that failed hides its calls, so nothing can be
+	// ruled out.
+	rootFailed := false
+	for _, pkg := range pkgs {
+		rootFailed = rootFailed || failed[pkg.PkgPath]
+	}
+	if len(failed) > 0 {
+		report(ctx, Event{Kind: EventWarning, Message: fmt.Sprintf("%d packages failed to load, their reachability is unknown: %s", len(failed), firstErr)})
+	}
+
+	prog, roots := ssautil.AllPackages(pkgs, ssa.InstantiateGenerics)
+	prog.Build()
+	graph := vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+	graph.DeleteSyntheticNodes()
+	parents := reachable(graph, entryPoints(prog, roots))
+
+	for i := range vulns {
+		v := &vulns[i]
+		v.Reachable, v.Trace = ReachNone, nil
+		for fn := range parents {
+			pkg, name := symbol(fn)
+			if !v.matches(pkg, built[pkg], name) {
+				continue
+			}
+			if trace := callTrace(parents, fn); v.Trace == nil || shorter(trace, v.Trace) {
+				v.Reachable, v.Trace = ReachCalled, trace
+			}
+		}
+		if v.Reachable == ReachCalled {
+			continue
+		}
+		if rootFailed {
+			v.Reachable = ReachUnknown
+			continue
+		}
+		for path := range failed {
+			if v.matchesPackage(path, built[path]) {
+				v.Reachable = ReachUnknown
+				break
+			}
+		}
+		if v.Reachable == ReachUnknown {
+			continue
+		}
+		for path, mod := range built {
+			if v.matchesPackage(path, mod) {
+				v.Reachable = ReachImported
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Vulnerability) matches(pkg, mod, name string) bool {
+	if len(v.imports) == 0 {
+		return v.matchesPackage(pkg, mod)
+	}
+	for _, imp := range v.imports {
+		if imp.Path != pkg {
+			continue
+		}
+		if len(imp.Symbols) == 0 {
+			return true
+		}
+		for _, sym := range imp.Symbols {
+			if sym == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesPackage reports whether the package path, from module mod, is one
+// the vulnerability lists, or when it lists none, belongs to its module. The
+// module is compared rather than the path prefix, which would also take in
+// nested modules such as example.com/a/b for example.com/a.
+func (v *Vulnerability) matchesPackage(path, mod string) bool {
+	if len(v.imports) == 0 {
+		return mod == v.Module
+	}
+	for _, imp := range v.imports {
+		if imp.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// entryPoints returns main and init when the project builds a command.
+// Otherwise it is a library, and every exported function and method counts
+// as well, since any of them may be called by an importer.
+func entryPoints(prog *ssa.Program, pkgs []*ssa.Package) []*ssa.Function {
+	command := false
+	for _, pkg := range pkgs {
+		command = command || (pkg != nil && pkg.Pkg.Name() == "main")
+	}
+
+	var roots []*ssa.Function
+	for _, pkg := range pkgs {
+		if pkg == nil {
+			continue
+		}
+		for _, name := range []string{"init", "main"} {
+			if fn := pkg.Func(name); fn != nil && (name == "init" || pkg.Pkg.Name() == "main") {
+				roots = append(roots, fn)
+			}
+		}
+		if command {
+			continue
+		}
+		for _, member := range pkg.Members {
+			if !token.IsExported(member.Name()) {
+				continue
+			}
+			switch m := member.(type) {
+			case *ssa.Function:
+				roots = append(roots, m)
+			case *ssa.Type:
+				for _, t := range []types.Type{m.Type(), types.NewPointer(m.Type())} {
+					mset := prog.MethodSets.MethodSet(t)
+					for i := 0; i < mset.Len(); i++ {
+						if fn := prog.MethodValue(mset.At(i)); fn != nil && mset.At(i).Obj().Exported() {
+							roots = append(roots, fn)
+						}
+					}
+				}
+			}
+		}
+	}
+	return roots
+}
+
+// reachable walks the call graph breadth-first from roots and returns every
+// function reached, mapped to the caller it was first reached from.
+func reachable(graph *callgraph.Graph, roots []*ssa.Function) map[*ssa.Function]*ssa.Function {
+	parents := map[*ssa.Function]*ssa.Function{}
+	var queue []*ssa.Function
+	for _, fn := range roots {
+		if _, seen := parents[fn]; !seen {
+			parents[fn] = nil
+			queue = append(queue, fn)
+		}
+	}
+	for len(queue) > 0 {
+		fn := queue[0]
+		queue = queue[1:]
+		node := graph.Nodes[fn]
+		if node == nil {
+			continue
+		}
+		for _, edge := range node.Out {
+			callee := edge.Callee.Func
+			if _, seen := parents[callee]; !seen {
+				parents[callee] = fn
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return parents
+}
+
+// callTrace renders the call path from an entry point to fn.
+func callTrace(parents map[*ssa.Function]*ssa.Function, fn *ssa.Function) []string {
+	var trace []string
+	for ; fn != nil; fn = parents[fn] {
+		pkg, name := symbol(fn)
+		trace = append([]string{pkg + "." + name}, trace...)
+	}
+	return trace
+}
+
+// shorter orders traces by length, then by name, so the reported trace does
+// not depend on map order.
+func shorter(a, b []string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return strings.Join(a, " ") < strings.Join(b, " ")
+}
+
+// symbol names fn the way vulndb entries do: "Func" or "Type.Method", with
+// closures and generic instances attributed to the function they come from.
+func symbol(fn *ssa.Function) (string, string) {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	if fn.Origin() != nil {
+		fn = fn.Origin()
+	}
+	if fn.Pkg == nil {
+		return "", fn.Name()
+	}
+	name := fn.Name()
+	if recv := fn.Signature.Recv(); recv != nil {
+		t := recv.Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			name = named.Obj().Name() + "." + name
+		}
+	}
+	return fn.Pkg.Pkg.Path(), name
+}
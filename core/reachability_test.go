@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestVulnerabilityMatchesPackage(t *testing.T) {
+	byModule := &Vulnerability{Module: "example.com/a"}
+	byImport := &Vulnerability{Module: "example.com/a", imports: []OSVImport{
+		{Path: "example.com/a/client", Symbols: []string{"Client.Do"}},
+		{Path: "example.com/a/all"},
+	}}
+	tests := []struct {
+		v         *Vulnerability
+		pkg, mod  string
+		name      string
+		inPackage bool
+		called    bool
+	}{
+		{byModule, "example.com/a", "example.com/a", "Parse", true, true},
+		{byModule, "example.com/a/sub", "example.com/a", "Parse", true, true},
+		{byModule, "example.com/a/b", "example.com/a/b", "Parse", false, false},
+		{byModule, "example.com/a/b/sub", "example.com/a/b", "Parse", false, false},
+		{byModule, "example.com/ab", "example.com/ab", "Parse", false, false},
+		{byModule, "fmt", "", "Println", false, false},
+		{byImport, "example.com/a/client", "example.com/a", "Client.Do", true, true},
+		{byImport, "example.com/a/client", "example.com/a", "Client.Close", true, false},
+		{byImport, "example.com/a/all", "example.com/a", "Anything", true, true},
+		{byImport, "example.com/a", "example.com/a", "Parse", false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.v.matchesPackage(tt.pkg, tt.mod); got != tt.inPackage {
+			t.Errorf("%s matchesPackage(%s, %s) = %v", tt.v.Module, tt.pkg, tt.mod, got)
+		}
+		if got := tt.v.matches(tt.pkg, tt.mod, tt.name); got != tt.called {
+			t.Errorf("%s matches(%s, %s) = %v", tt.v.Module, tt.pkg, tt.name, got)
+		}
+	}
+}
+
+// A vulnerability in example.com/a that lists no packages must not be
+// reported as imported when only the nested module example.com/a/b is built.
+func TestReachabilityNestedModule(t *testing.T) {
+	proxy := newTestProxy(t,
+		testModule{Path: "example.com/a", Version: "v1.0.0", Files: map[string]string{"a.go": "package a\n\nfunc Parse() {}\n"}},
+		testModule{Path: "example.com/a/b", Version: "v1.0.0", Files: map[string]string{"b.go": "package b\n\nfunc Run() {}\n"}},
+	)
+	p := newTestProject(t, proxy, `name = "app"
+
+[dependencies]
+"example.com/a" = "v1.0.0"
+"example.com/a/b" = "v1.0.0"
+`)
+	ctx := context.Background()
+	result, err := p.Install(ctx, InstallOptions{})
+	if err == nil {
+		err = errors.Join(result.Errors()...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(p.Layout.Root, "main.go"), "package main\n\nimport \"example.com/a/b\"\n\nfunc main() { b.Run() }\n")
+
+	vulns := []Vulnerability{
+		{ID: "GO-2099-0100", Module: "example.com/a"},
+		{ID: "GO-2099-0101", Module: "example.com/a/b"},
+	}
+	if err := p.Reachability(ctx, vulns); err != nil {
+		t.Fatal(err)
+	}
+	if vulns[0].Reachable != ReachNone {
+		t.Errorf("example.com/a: %s, want %s", vulns[0].Reachable, ReachNone)
+	}
+	if vulns[1].Reachable != ReachCalled {
+		t.Errorf("example.com/a/b: %s, want %s", vulns[1].Reachable, ReachCalled)
+	}
+}
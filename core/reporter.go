@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventInstallStart     EventKind = "install_start"
+	EventResolveStart     EventKind = "resolve_start"
+	EventResolveDone      EventKind = "resolve_done"
+	EventCacheHit         EventKind = "cache_hit"
+	EventDownloadStart    EventKind = "download_start"
+	EventDownloadProgress EventKind = "download_progress"
+	EventDownloadDone     EventKind = "download_done"
+	EventExtractStart     EventKind = "extract_start"
+	EventExtractDone      EventKind = "extract_done"
+	EventWarning          EventKind = "warning"
+)
+
+type Event struct {
+	Kind    EventKind `json:"kind"`
+	Module  string    `json:"module,omitempty"`
+	Version string    `json:"version,omitempty"`
+	// Bytes and Total describe download progress; Total is -1 when the
+	// server did not send a Content-Length.
+	Bytes   int64  `json:"bytes,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+	Files   int    `json:"files,omitempty"`
+	Index   int    `json:"index,omitempty"`
+	Count   int    `json:"count,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type Reporter interface {
+	Report(Event)
+}
+
+type ReporterFunc func(Event)
+
+func (f ReporterFunc) Report(e Event) { f(e) }
+
+type reporterKey struct{}
+
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, r)
+}
+
+func report(ctx context.Context, e Event) {
+	if ctx == nil {
+		return
+	}
+	if r, ok := ctx.Value(reporterKey{}).(Reporter); ok && r != nil {
+		r.Report(e)
+	}
+}
+
+const progressInterval = 100 * time.Millisecond
+
+type progressWriter struct {
+	ctx     context.Context
+	module  string
+	version string
+	written int64
+	total   int64
+	last    time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n := len(b)
+	p.written += int64(n)
+	if now := time.Now(); now.Sub(p.last) >= progressInterval || p.written == p.total {
+		p.last = now
+		p.emit()
+	}
+	return n, nil
+}
+
+func (p *progressWriter) emit() {
+	report(p.ctx, Event{
+		Kind:    EventDownloadProgress,
+		Module:  p.module,
+		Version: p.version,
+		Bytes:   p.written,
+		Total:   p.total,
+	})
+}
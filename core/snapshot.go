@@ -0,0 +1,325 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// maxSnapshots is how many snapshots are kept for `gopkg rollback`.
+const maxSnapshots = 20
+
+// Snapshot is a saved copy of the files a mutating command rewrites.
+type Snapshot struct {
+	ID      string    `json:"id" yaml:"id"`
+	Command string    `json:"command" yaml:"command"`
+	Time    time.Time `json:"time" yaml:"time"`
+	// Files lists the tracked files that were saved, and Missing the ones
+	// that did not exist yet and are deleted on restore.
+	Files   []string `json:"files" yaml:"files"`
+	Missing []string `json:"missing,omitempty" yaml:"missing,omitempty"`
+}
+
+// Transaction snapshots the project files before a mutating command and
+// either keeps the snapshot for `gopkg rollback` or restores it.
+type Transaction struct {
+	project *Project
+	snap    Snapshot
+	dir     string
+	lock    map[string]LockEntry
+	before  projectState
+	touched bool
+	done    bool
+}
+
+// trackedFiles maps snapshot names to the files they are restored to:
+// the manifest, the lock, the Go files gopkg edits and, in a workspace,
+// every member's go.mod and go.sum.
+func (p *Project) trackedFiles() map[string]string {
+	files := p.memberFiles()
+	files["gopkg.toml"] = p.Layout.TomlPath()
+	files["gopkg.lock"] = p.Layout.LockPath()
+	for _, name := range []string{"go.mod", "go.sum", "go.work", "go.work.sum"} {
+		files[name] = filepath.Join(p.Layout.Root, name)
+	}
+	return files
+}
+
+func (p *Project) snapshotsDir() string {
+	return filepath.Join(p.Layout.StateDir(), "snapshots")
+}
+
+// Begin snapshots the manifest, the lockfile and the Go files gopkg edits.
+func (p *Project) Begin(command string) (*Transaction, error) {
+	before := p.state()
+	now := time.Now().UTC()
+	snap := Snapshot{ID: now.Format("20060102T150405.000000000"), Command: command, Time: now}
+	dir := filepath.Join(p.snapshotsDir(), snap.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	tracked := p.trackedFiles()
+	for _, name := range sortedKeys(tracked) {
+		data, err := os.ReadFile(tracked[name])
+		if errors.Is(err, os.ErrNotExist) {
+			snap.Missing = append(snap.Missing, name)
+			continue
+		}
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, name), data, 0644)
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to snapshot %s: %w", name, err)
+		}
+		snap.Files = append(snap.Files, name)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "snapshot.json"), data, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return &Transaction{project: p, snap: snap, dir: dir, lock: p.lockMap(), before: before}, nil
+}
+
+// Snapshot returns the state saved when the transaction began.
+func (tx *Transaction) Snapshot() Snapshot { return tx.snap }
+
+// Commit keeps the snapshot for `gopkg rollback` and records the command in
+// the history, unless it left every tracked file as it was.
+func (tx *Transaction) Commit() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	if !tx.touched && !tx.changed() {
+		return os.RemoveAll(tx.dir)
+	}
+	if err := tx.project.recordHistory(tx.snap.Command, tx.snap.ID, tx.before); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+	return tx.project.pruneSnapshots(maxSnapshots)
+}
+
+// Touch makes Commit record the command even if no tracked file changed,
+// for commands such as clean that only delete installed modules.
+func (tx *Transaction) Touch() { tx.touched = true }
+
+// Rollback restores the tracked files and drops the snapshot.
+func (tx *Transaction) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	if err := tx.project.restore(tx.dir, tx.snap); err != nil {
+		return err
+	}
+	return os.RemoveAll(tx.dir)
+}
+
+// discard drops the snapshot and leaves the files as they are, without
+// recording the command.
+func (tx *Transaction) discard() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	return os.RemoveAll(tx.dir)
+}
+
+// finish commits when the command succeeded and rolls back otherwise,
+// removing module directories that no longer match the resulting lock.
+func (tx *Transaction) finish(ctx context.Context, failed bool, results []ModuleResult) (bool, error) {
+	if !failed {
+		if err := tx.Commit(); err != nil {
+			return false, err
+		}
+		current := tx.project.lockMap()
+		for module, entry := range tx.lock {
+			if entry.Resolved != "" && current[module].Resolved != entry.Resolved {
+				os.RemoveAll(tx.project.Layout.ModuleDir(module, entry.Resolved))
+			}
+		}
+		return false, nil
+	}
+	changed := tx.changed()
+	if err := tx.Rollback(); err != nil {
+		return false, fmt.Errorf("rollback failed: %w", err)
+	}
+	for _, r := range results {
+		if r.Resolved != "" && r.Path == "" && tx.lock[r.Module].Resolved != r.Resolved {
+			os.RemoveAll(tx.project.Layout.ModuleDir(r.Module, r.Resolved))
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	report(ctx, Event{Kind: EventWarning, Message: tx.snap.Command + " failed, restored gopkg.toml, gopkg.lock and the Go module files"})
+	return true, nil
+}
+
+func (tx *Transaction) changed() bool {
+	root := tx.project.Layout.Root
+	paths := tx.project.trackedFiles()
+	for _, name := range tx.snap.Missing {
+		if _, err := os.Stat(snapshotPath(paths, root, name)); err == nil {
+			return true
+		}
+	}
+	for _, name := range tx.snap.Files {
+		before, _ := os.ReadFile(filepath.Join(tx.dir, name))
+		current, err := os.ReadFile(snapshotPath(paths, root, name))
+		if err != nil || !bytes.Equal(before, current) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotPath maps a snapshot name back to its file; names that are not
+// tracked any more are paths relative to the project root.
+func snapshotPath(tracked map[string]string, root, name string) string {
+	if path, ok := tracked[name]; ok {
+		return path
+	}
+	return filepath.Join(root, filepath.FromSlash(name))
+}
+
+func (p *Project) restore(dir string, snap Snapshot) error {
+	var errs []error
+	tracked := p.trackedFiles()
+	for _, name := range snap.Missing {
+		if err := os.Remove(snapshotPath(tracked, p.Layout.Root, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range snap.Files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			err = os.WriteFile(snapshotPath(tracked, p.Layout.Root, name), data, 0644)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("restore %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Snapshots lists the saved snapshots, newest first.
+func (p *Project) Snapshots() ([]Snapshot, error) {
+	entries, err := os.ReadDir(p.snapshotsDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(p.snapshotsDir(), e.Name(), "snapshot.json"))
+		if err != nil {
+			continue
+		}
+		var s Snapshot
+		if json.Unmarshal(data, &s) == nil && s.ID == e.Name() {
+			snaps = append(snaps, s)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].ID > snaps[j].ID })
+	return snaps, nil
+}
+
+func (p *Project) pruneSnapshots(keep int) error {
+	snaps, err := p.Snapshots()
+	if err != nil || len(snaps) <= keep {
+		return err
+	}
+	for _, s := range snaps[keep:] {
+		if err := os.RemoveAll(filepath.Join(p.snapshotsDir(), s.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback restores the given snapshot, or the newest one when id is empty,
+// then reinstalls so gopkg_modules matches the restored lock. The snapshot
+// and every newer one are consumed, so repeated rollbacks walk further back.
+func (p *Project) Rollback(ctx context.Context, id string) (*Snapshot, *InstallResult, error) {
+	snaps, err := p.Snapshots()
+	if err != nil {
+		return nil, nil, err
+	}
+	var snap *Snapshot
+	var newer []Snapshot
+	for i := range snaps {
+		if id == "" || snaps[i].ID == id {
+			snap, newer = &snaps[i], snaps[:i+1]
+			break
+		}
+	}
+	if snap == nil {
+		if id == "" {
+			return nil, nil, NewError(ErrNotFound, "no snapshots to roll back to")
+		}
+		return nil, nil, NewError(ErrNotFound, "snapshot %s not found", id)
+	}
+
+	before := p.state()
+	current := p.lockMap()
+	dir := filepath.Join(p.snapshotsDir(), snap.ID)
+	if err := p.restore(dir, *snap); err != nil {
+		return snap, nil, err
+	}
+	restored := p.lockMap()
+	for module, entry := range current {
+		if restored[module].Resolved != entry.Resolved {
+			os.RemoveAll(p.Layout.ModuleDir(module, entry.Resolved))
+		}
+	}
+	for _, s := range newer {
+		if err := os.RemoveAll(filepath.Join(p.snapshotsDir(), s.ID)); err != nil {
+			return snap, nil, err
+		}
+	}
+
+	var result *InstallResult
+	if _, err = os.Stat(p.Layout.TomlPath()); err == nil {
+		result, err = p.reinstall(ctx)
+	} else {
+		err = nil
+	}
+	if herr := p.recordHistory("rollback", "", before); herr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to record history: %w", herr))
+	}
+	return snap, result, err
+}
+
+// reinstall brings the modules directory in line with a restored lock. If it
+// fails, the restored files are put back, so the lock never describes a
+// partial install.
+func (p *Project) reinstall(ctx context.Context) (*InstallResult, error) {
+	tx, err := p.Begin("rollback")
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.install(ctx, InstallOptions{})
+	if err == nil && result != nil && len(result.Errors()) == 0 {
+		return result, tx.discard()
+	}
+	return result, p.finishInstall(ctx, tx, result, err)
+}
@@ -1,22 +1,26 @@
 package core
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 )
 
-func ResolveLatestVersion(module string) (string, error) {
-	url := fmt.Sprintf("https://proxy.golang.org/%s/@latest", module)
-	resp, err := http.Get(url)
+func ResolveLatestVersion(ctx context.Context, module string) (string, error) {
+	return DefaultProxy().Latest(ctx, module)
+}
+
+func (p *Proxy) Latest(ctx context.Context, module string) (string, error) {
+	resp, err := p.get(ctx, p.moduleURL(module, "@latest"), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve latest: %w", err)
+		return "", networkError(module, "latest", fmt.Errorf("failed to resolve latest: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("failed to resolve latest: status %d", resp.StatusCode)
+		return "", statusError(module, "latest", "latest version", resp.StatusCode)
 	}
 
 	var data struct {
@@ -30,6 +34,34 @@ func ResolveLatestVersion(module string) (string, error) {
 	return data.Version, nil
 }
 
+func FetchVersionList(ctx context.Context, module string) ([]string, error) {
+	return DefaultProxy().Versions(ctx, module)
+}
+
+func (p *Proxy) Versions(ctx context.Context, module string) ([]string, error) {
+	resp, err := p.get(ctx, p.moduleURL(module, "@v/list"), nil)
+	if err != nil {
+		return nil, networkError(module, "", fmt.Errorf("failed to fetch versions: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, statusError(module, "", "version list", resp.StatusCode)
+	}
+
+	var versions []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if v := strings.TrimSpace(scanner.Text()); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, networkError(module, "", fmt.Errorf("error reading versions: %w", err))
+	}
+	return versions, nil
+}
+
 func CompareVersions(v1, v2 string) int {
 	v1 = strings.TrimPrefix(v1, "v")
 	v2 = strings.TrimPrefix(v2, "v")
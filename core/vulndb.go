@@ -0,0 +1,374 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/pageton/gopkg/core/httpclient"
+)
+
+const defaultVulnDB = "https://vuln.go.dev"
+
+// OSVEntry is a vulnerability in the OSV format used by the Go vulnerability
+// database. Only the fields gopkg reports on are decoded.
+type OSVEntry struct {
+	ID               string        `json:"id"`
+	Modified         string        `json:"modified,omitempty"`
+	Aliases          []string      `json:"aliases,omitempty"`
+	Summary          string        `json:"summary,omitempty"`
+	Details          string        `json:"details,omitempty"`
+	Severity         []OSVSeverity `json:"severity,omitempty"`
+	Affected         []OSVAffected `json:"affected"`
+	DatabaseSpecific struct {
+		URL      string `json:"url,omitempty"`
+		Severity string `json:"severity,omitempty"`
+	} `json:"database_specific"`
+}
+
+type OSVSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type OSVAffected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges            []OSVRange `json:"ranges,omitempty"`
+	EcosystemSpecific struct {
+		Imports []OSVImport `json:"imports,omitempty"`
+	} `json:"ecosystem_specific"`
+}
+
+// OSVImport names the vulnerable symbols of a package, "Func" or
+// "Type.Method"; no symbols means the whole package.
+type OSVImport struct {
+	Path    string   `json:"path"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// OSVRange lists introduced/fixed events; versions have no "v" prefix and
+// "0" stands for the first version.
+type OSVRange struct {
+	Type   string     `json:"type"`
+	Events []OSVEvent `json:"events"`
+}
+
+type OSVEvent struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+// VulnDB reads a database in the vuln.go.dev layout (index/modules.json and
+// ID/<id>.json) from a server or a local directory.
+type VulnDB struct {
+	Source string
+	dir    string
+	client *httpclient.Client
+
+	mu      sync.Mutex
+	modules map[string][]string
+}
+
+// OpenVulnDB accepts an http(s) URL, a file:// URL or a directory.
+func OpenVulnDB(source string) *VulnDB {
+	db := &VulnDB{Source: strings.TrimSuffix(source, "/")}
+	switch u, err := url.Parse(source); {
+	case err == nil && (u.Scheme == "http" || u.Scheme == "https"):
+		db.client = httpclient.New(EnvHTTPOptions()...)
+	case err == nil && u.Scheme == "file":
+		db.dir = filepath.FromSlash(u.Path)
+	default:
+		db.dir = source
+	}
+	return db
+}
+
+// DefaultVulnDB picks the database to audit against: the vulndb setting when
+// it was set explicitly, else the copy downloaded with `gopkg audit
+// --update-db`, else the default server.
+func (p *Project) DefaultVulnDB() string {
+	cfg := p.config()
+	if v, err := cfg.Get("vulndb"); err == nil && v.Source != SourceDefault {
+		return v.Value
+	}
+	dir := p.Layout.VulnDBDir()
+	if _, err := os.Stat(filepath.Join(dir, "index", "modules.json")); err == nil {
+		return dir
+	}
+	return cfg.VulnDB()
+}
+
+func (db *VulnDB) read(ctx context.Context, name string) ([]byte, error) {
+	if db.client == nil {
+		data, err := os.ReadFile(filepath.Join(db.dir, filepath.FromSlash(name)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, NewError(ErrNotFound, "vulnerability database %s has no %s", db.Source, name)
+		}
+		return data, err
+	}
+
+	resp, err := db.client.Get(ctx, db.Source+"/"+name)
+	if err != nil {
+		return nil, NewError(ErrNetwork, "vulnerability database %s: %v", RedactURL(db.Source), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		kind := ErrNetwork
+		if resp.StatusCode == http.StatusNotFound {
+			kind = ErrNotFound
+		}
+		return nil, NewError(kind, "vulnerability database %s: %s returned status %d", RedactURL(db.Source), name, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Modules returns the IDs of the entries that affect each module.
+func (db *VulnDB) Modules(ctx context.Context) (map[string][]string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.modules != nil {
+		return db.modules, nil
+	}
+
+	data, err := db.read(ctx, "index/modules.json")
+	if err != nil {
+		return nil, err
+	}
+	var index []struct {
+		Path  string `json:"path"`
+		Vulns []struct {
+			ID string `json:"id"`
+		} `json:"vulns"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid index/modules.json in %s: %w", db.Source, err)
+	}
+	db.modules = map[string][]string{}
+	for _, m := range index {
+		for _, v := range m.Vulns {
+			db.modules[m.Path] = append(db.modules[m.Path], v.ID)
+		}
+	}
+	return db.modules, nil
+}
+
+func (db *VulnDB) Entry(ctx context.Context, id string) (*OSVEntry, error) {
+	data, err := db.read(ctx, "ID/"+id+".json")
+	if err != nil {
+		return nil, err
+	}
+	var e OSVEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("invalid entry %s in %s: %w", id, db.Source, err)
+	}
+	return &e, nil
+}
+
+// Download copies the index and every entry into dir for offline use,
+// replacing the previous copy only once everything was fetched.
+func (db *VulnDB) Download(ctx context.Context, dir string, jobs int) (int, error) {
+	modules, err := db.Modules(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ids := map[string]string{}
+	for _, list := range modules {
+		for _, id := range list {
+			ids[id] = ""
+		}
+	}
+
+	tmp := dir + ".tmp"
+	os.RemoveAll(tmp)
+	for _, sub := range []string{"index", "ID"} {
+		if err := os.MkdirAll(filepath.Join(tmp, sub), 0755); err != nil {
+			return 0, err
+		}
+	}
+	names := []string{"index/modules.json"}
+	if _, err := db.read(ctx, "index/db.json"); err == nil {
+		names = append(names, "index/db.json")
+	}
+	for _, id := range sortedKeys(ids) {
+		names = append(names, "ID/"+id+".json")
+	}
+
+	if jobs < 1 {
+		jobs = 1
+	}
+	sem := make(chan struct{}, jobs)
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			data, err := db.read(ctx, name)
+			if err == nil {
+				err = os.WriteFile(filepath.Join(tmp, filepath.FromSlash(name)), data, 0644)
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		os.RemoveAll(tmp)
+		return 0, err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// affects reports whether version of module falls in one of the entry's
+// ranges, and the lowest fixed version above it when there is one.
+func (e *OSVEntry) affects(module, version string) (bool, string) {
+	hit, fixed := false, ""
+	for _, a := range e.Affected {
+		if a.Package.Name != module {
+			continue
+		}
+		if len(a.Ranges) == 0 {
+			hit = true
+		}
+		for _, r := range a.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			if in, fix := r.contains(version); in {
+				hit = true
+				if fix != "" && (fixed == "" || semver.Compare(fix, fixed) < 0) {
+					fixed = fix
+				}
+			}
+		}
+	}
+	return hit, fixed
+}
+
+func (r OSVRange) contains(version string) (bool, string) {
+	events := append([]OSVEvent(nil), r.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return semver.Compare(events[i].version(), events[j].version()) < 0
+	})
+	affected := false
+	for i, e := range events {
+		if semver.Compare(version, e.version()) < 0 {
+			for _, later := range events[i:] {
+				if affected && later.Fixed != "" {
+					return true, later.version()
+				}
+			}
+			break
+		}
+		affected = e.Introduced != ""
+	}
+	return affected, ""
+}
+
+func (e OSVEvent) version() string {
+	v := e.Introduced
+	if v == "" {
+		v = e.Fixed
+	}
+	if v == "0" {
+		return "v0.0.0"
+	}
+	return "v" + strings.TrimPrefix(v, "v")
+}
+
+// imports lists the vulnerable packages and symbols of module.
+func (e *OSVEntry) imports(module string) []OSVImport {
+	var imports []OSVImport
+	for _, a := range e.Affected {
+		if a.Package.Name == module {
+			imports = append(imports, a.EcosystemSpecific.Imports...)
+		}
+	}
+	return imports
+}
+
+// describe renders the ranges that cover module, e.g. ">=v1.2.0, <v1.2.5".
+func (e *OSVEntry) describe(module string) string {
+	var parts []string
+	for _, a := range e.Affected {
+		if a.Package.Name != module {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			intro := ""
+			for _, ev := range r.Events {
+				switch {
+				case ev.Introduced != "":
+					intro = ev.version()
+				case ev.Fixed != "":
+					parts = append(parts, rangeString(intro, ev.version()))
+					intro = ""
+				}
+			}
+			if intro != "" {
+				parts = append(parts, rangeString(intro, ""))
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return "all versions"
+	}
+	return strings.Join(parts, " || ")
+}
+
+func rangeString(introduced, fixed string) string {
+	var s []string
+	if introduced != "" && introduced != "v0.0.0" {
+		s = append(s, ">="+introduced)
+	}
+	if fixed != "" {
+		s = append(s, "<"+fixed)
+	}
+	if len(s) == 0 {
+		return "all versions"
+	}
+	return strings.Join(s, ", ")
+}
+
+// severity prefers the database's own rating, then the rating of a CVSS v3
+// vector, which is returned along with its base score.
+func (e *OSVEntry) severity() (string, float64, string) {
+	for _, s := range e.Severity {
+		if score, ok := cvssScore(s.Score); ok {
+			rating := cvssRating(score)
+			if e.DatabaseSpecific.Severity != "" {
+				rating = strings.ToLower(e.DatabaseSpecific.Severity)
+			}
+			return rating, score, s.Score
+		}
+	}
+	if s := e.DatabaseSpecific.Severity; s != "" {
+		return strings.ToLower(s), 0, ""
+	}
+	return "unknown", 0, ""
+}
@@ -0,0 +1,326 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// WorkspaceConfig is the [workspace] table of a root gopkg.toml.
+type WorkspaceConfig struct {
+	// Members are directories or globs, relative to the root, that each
+	// hold a gopkg.toml and a go.mod.
+	Members []string `toml:"members"`
+}
+
+type WorkspaceMember struct {
+	// Dir is relative to the workspace root, e.g. "services/api".
+	Dir      string
+	Module   string
+	Manifest *GopkgToml
+}
+
+// Workspace installs the dependencies of several projects together into the
+// root project's modules directory, with one lockfile and a go.work that
+// ties the members together.
+type Workspace struct {
+	Root    *Project
+	Members []WorkspaceMember
+}
+
+// LoadWorkspace reads the [workspace] table of the project's gopkg.toml and
+// every member it matches.
+func (p *Project) LoadWorkspace() (*Workspace, error) {
+	cfg, err := p.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", p.Layout.TomlPath(), err)
+	}
+	if cfg.Workspace == nil || len(cfg.Workspace.Members) == 0 {
+		return nil, NewError(ErrNotFound, "%s has no [workspace] members", p.Layout.TomlPath())
+	}
+
+	dirs, err := p.workspaceDirs(cfg.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	ws := &Workspace{Root: p}
+	for _, dir := range dirs {
+		full := filepath.Join(p.Layout.Root, filepath.FromSlash(dir))
+		manifest, err := LoadToml(filepath.Join(full, "gopkg.toml"))
+		if err != nil {
+			return nil, fmt.Errorf("workspace member %s: %w", dir, err)
+		}
+		module, err := GoMod{Dir: full}.ModulePath()
+		if err != nil {
+			return nil, fmt.Errorf("workspace member %s: %w", dir, err)
+		}
+		ws.Members = append(ws.Members, WorkspaceMember{Dir: dir, Module: module, Manifest: manifest})
+	}
+	return ws, nil
+}
+
+// workspaceDirs expands the member globs to directories with a gopkg.toml.
+func (p *Project) workspaceDirs(wc *WorkspaceConfig) ([]string, error) {
+	seen := map[string]bool{}
+	var dirs []string
+	for _, pattern := range wc.Members {
+		matches, err := filepath.Glob(filepath.Join(p.Layout.Root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace member pattern %q: %w", pattern, err)
+		}
+		for _, m := range matches {
+			if _, err := os.Stat(filepath.Join(m, "gopkg.toml")); err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(p.Layout.Root, m)
+			if err != nil {
+				return nil, err
+			}
+			rel = filepath.ToSlash(rel)
+			if rel != "." && !seen[rel] {
+				seen[rel] = true
+				dirs = append(dirs, rel)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// memberFiles lists the go.mod and go.sum of every member, keyed by their
+// path relative to the root, so transactions can snapshot them.
+func (p *Project) memberFiles() map[string]string {
+	files := map[string]string{}
+	cfg, err := p.LoadManifest()
+	if err != nil || cfg.Workspace == nil {
+		return files
+	}
+	dirs, _ := p.workspaceDirs(cfg.Workspace)
+	for _, dir := range dirs {
+		for _, name := range []string{"go.mod", "go.sum"} {
+			rel := dir + "/" + name
+			files[rel] = filepath.Join(p.Layout.Root, filepath.FromSlash(rel))
+		}
+	}
+	return files
+}
+
+// Manifest merges the root's and every member's dependencies. Modules that
+// are themselves members are left out, go.work resolves them locally. When
+// members declare different constraints for a module, the highest version
+// satisfying all of them is picked.
+func (w *Workspace) Manifest(ctx context.Context) (*GopkgToml, error) {
+	root, err := w.Root.LoadManifest()
+	if err != nil {
+		return nil, err
+	}
+	local := w.localModules()
+
+	wants := map[string]map[string][]string{}
+	add := func(who string, deps map[string]string) {
+		for module, version := range deps {
+			if _, ok := local[module]; ok {
+				continue
+			}
+			if wants[module] == nil {
+				wants[module] = map[string][]string{}
+			}
+			wants[module][version] = append(wants[module][version], who)
+		}
+	}
+	add(".", root.Dependencies)
+	for _, m := range w.Members {
+		add(m.Dir, m.Manifest.Dependencies)
+	}
+
+	merged := &GopkgToml{Name: root.Name, Dependencies: map[string]string{}, Links: root.Links, Policy: root.Policy, Workspace: root.Workspace}
+	for _, module := range sortedKeys(wants) {
+		if _, linked := root.Links[module]; linked {
+			// Linked modules are never fetched, any declared version does.
+			merged.Dependencies[module] = sortedKeys(wants[module])[0]
+			continue
+		}
+		version, err := w.unify(ctx, module, wants[module])
+		if err != nil {
+			return nil, err
+		}
+		merged.Dependencies[module] = version
+	}
+	return merged, nil
+}
+
+// hash covers the dependencies the root and every member declare, so
+// changing any of them makes the shared lock stale.
+func (w *Workspace) hash(root *GopkgToml) string {
+	deps := map[string]string{}
+	for module, version := range root.Dependencies {
+		deps[module] = version
+	}
+	for _, m := range w.Members {
+		for module, version := range m.Manifest.Dependencies {
+			deps[m.Dir+":"+module] = version
+		}
+	}
+	return ManifestHash(deps)
+}
+
+// declares reports whether a member other than the root declares module.
+func (w *Workspace) declares(module string) bool {
+	for _, m := range w.Members {
+		if _, ok := m.Manifest.Dependencies[module]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// workspace returns the workspace rooted at the project, or nil when its
+// manifest has no [workspace] members.
+func (p *Project) workspace(cfg *GopkgToml) (*Workspace, error) {
+	if p.Layout.Global || cfg.Workspace == nil || len(cfg.Workspace.Members) == 0 {
+		return nil, nil
+	}
+	return p.LoadWorkspace()
+}
+
+// manifestHash is the hash recorded in the lock for cfg: its dependencies,
+// or at a workspace root every member's as well.
+func (p *Project) manifestHash(cfg *GopkgToml) (string, error) {
+	ws, err := p.workspace(cfg)
+	if err != nil || ws == nil {
+		return ManifestHash(cfg.Dependencies), err
+	}
+	root, err := p.LoadManifest()
+	if err != nil {
+		return "", err
+	}
+	return ws.hash(root), nil
+}
+
+// unify turns the constraints members declared for module into one.
+func (w *Workspace) unify(ctx context.Context, module string, wants map[string][]string) (string, error) {
+	if len(wants) == 1 {
+		for version := range wants {
+			return version, nil
+		}
+	}
+
+	var describe []string
+	for _, version := range sortedKeys(wants) {
+		describe = append(describe, fmt.Sprintf("%s wants %s", strings.Join(wants[version], ", "), version))
+	}
+	var constraints []Constraint
+	for _, version := range sortedKeys(wants) {
+		c, err := ParseConstraint(version)
+		if err != nil {
+			return "", &ModuleError{Module: module, Kind: ErrConflict, Err: fmt.Errorf("cannot combine %s", strings.Join(describe, "; "))}
+		}
+		constraints = append(constraints, c)
+	}
+
+	versions, err := w.Root.releases(ctx, module)
+	if err != nil {
+		return "", err
+	}
+	semver.Sort(versions)
+	for i := len(versions) - 1; i >= 0; i-- {
+		ok := true
+		for _, c := range constraints {
+			ok = ok && c.Match(versions[i])
+		}
+		if ok {
+			return versions[i], nil
+		}
+	}
+	return "", &ModuleError{Module: module, Kind: ErrConflict, Err: fmt.Errorf("no version satisfies every member: %s", strings.Join(describe, "; "))}
+}
+
+func (w *Workspace) localModules() map[string]string {
+	local := map[string]string{}
+	for _, m := range w.Members {
+		local[m.Module] = m.Dir
+	}
+	return local
+}
+
+// Install resolves every member's dependencies together, installs them
+// once into the root's modules directory, records them in the root
+// gopkg.lock and writes go.work with a `use` for each member and a replace
+// for each installed module. Members' go.mod files only get require lines.
+func (w *Workspace) Install(ctx context.Context, opts InstallOptions) (*InstallResult, error) {
+	p := w.Root
+	tx, err := p.Begin("install -w")
+	if err != nil {
+		return nil, err
+	}
+	result, err := w.install(ctx, opts)
+	return result, p.finishInstall(ctx, tx, result, err)
+}
+
+func (w *Workspace) install(ctx context.Context, opts InstallOptions) (*InstallResult, error) {
+	p := w.Root
+	cfg, err := w.Manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	work := GoWork{Dir: p.Layout.Root}
+	if err := work.Init(); err != nil {
+		return nil, err
+	}
+	uses := make([]string, 0, len(w.Members)+1)
+	if p.GoMod().Exists() {
+		uses = append(uses, ".")
+	}
+	for _, m := range w.Members {
+		uses = append(uses, "./"+m.Dir)
+	}
+	if err := work.Use(uses...); err != nil {
+		return nil, err
+	}
+	if err := work.pruneReplaces(filepath.Base(p.Layout.ModulesDir()), cfg.Dependencies); err != nil {
+		return nil, err
+	}
+
+	r := workspaceReplacer{work: work, requirers: map[string][]GoMod{}}
+	for _, m := range w.Members {
+		gm := GoMod{Dir: filepath.Join(p.Layout.Root, filepath.FromSlash(m.Dir))}
+		for module := range m.Manifest.Dependencies {
+			r.requirers[module] = append(r.requirers[module], gm)
+		}
+	}
+	if root, err := p.LoadManifest(); err == nil && p.GoMod().Exists() {
+		for module := range root.Dependencies {
+			r.requirers[module] = append(r.requirers[module], p.GoMod())
+		}
+	}
+
+	result, err := p.installManifest(ctx, cfg, opts, r)
+	if result != nil {
+		for _, m := range w.Members {
+			result.Modules = append(result.Modules, ModuleResult{Module: m.Module, Path: "./" + m.Dir, Status: StatusLinked})
+		}
+	}
+	return result, err
+}
+
+// workspaceReplacer adds replaces to go.work and a require line to every
+// member that declares the module.
+type workspaceReplacer struct {
+	work      GoWork
+	requirers map[string][]GoMod
+}
+
+func (r workspaceReplacer) AddReplace(module, localPath, version string) error {
+	for _, gm := range r.requirers[module] {
+		if err := gm.Require(module, version); err != nil {
+			return err
+		}
+	}
+	return r.work.AddReplace(module, localPath, version)
+}
@@ -0,0 +1,129 @@
+// Package gopkg exposes the gopkg install pipeline as a library, so other
+// tools can install, add, remove and update dependencies without shelling
+// out to the CLI.
+package gopkg
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/pageton/gopkg/core"
+)
+
+type (
+	Reporter      = core.Reporter
+	ReporterFunc  = core.ReporterFunc
+	Event         = core.Event
+	EventKind     = core.EventKind
+	ModuleStatus  = core.ModuleStatus
+	ModuleResult  = core.ModuleResult
+	ModuleError   = core.ModuleError
+	InstallResult = core.InstallResult
+	LockEntry     = core.LockEntry
+	Metadata      = core.ModuleMetadata
+)
+
+type (
+	InstallOptions = core.InstallOptions
+	UpdateOptions  = core.UpdateOptions
+	UpdatePolicy   = core.UpdatePolicy
+)
+
+type Client struct {
+	project  *core.Project
+	proxyURL string
+	reporter Reporter
+}
+
+type Option func(*Client)
+
+// WithProxy sets the module proxy URL. Defaults to the proxy setting of the
+// project, GOPKG_PROXY, then GOPROXY.
+func WithProxy(url string) Option {
+	return func(c *Client) { c.proxyURL = url }
+}
+
+// WithCacheDir sets where downloaded module zips are kept.
+func WithCacheDir(dir string) Option {
+	return func(c *Client) { c.project.Layout.Cache = dir }
+}
+
+// WithReporter receives progress events for every call made by the client.
+func WithReporter(r Reporter) Option {
+	return func(c *Client) { c.reporter = r }
+}
+
+// WithRoot sets the project directory holding gopkg.toml and go.mod.
+func WithRoot(dir string) Option {
+	return func(c *Client) { c.project.Layout.Root = dir }
+}
+
+// WithHome puts all gopkg state (global manifest, modules, cache and
+// config) under dir, like GOPKG_HOME. Apply WithCacheDir after it to keep
+// the cache elsewhere.
+func WithHome(dir string) Option {
+	return func(c *Client) {
+		l := &c.project.Layout
+		l.Home, l.Cache, l.Config, l.Modules = dir, filepath.Join(dir, "cache"), dir, filepath.Join(dir, "modules")
+	}
+}
+
+// WithGlobal operates on the global manifest in the gopkg home directory.
+func WithGlobal(global bool) Option {
+	return func(c *Client) { c.project.Layout.Global = global }
+}
+
+func New(opts ...Option) (*Client, error) {
+	c := &Client{project: &core.Project{Layout: core.DefaultLayout(false)}}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var overrides []core.ConfigValue
+	if c.proxyURL != "" {
+		overrides = append(overrides, core.ConfigValue{Key: "proxy", Value: c.proxyURL, Source: core.SourceFlag, Origin: "WithProxy"})
+	}
+	project, err := core.LoadProject(c.project.Layout, overrides...)
+	if err != nil {
+		return nil, err
+	}
+	c.project = project
+	return c, nil
+}
+
+func (c *Client) ctx(ctx context.Context) context.Context {
+	if c.reporter == nil {
+		return ctx
+	}
+	return core.WithReporter(ctx, c.reporter)
+}
+
+// Install installs every dependency in gopkg.toml and rewrites gopkg.lock.
+// Per-module failures are reported in the result; see InstallResult.Errors.
+func (c *Client) Install(ctx context.Context, opts InstallOptions) (*InstallResult, error) {
+	return c.project.Install(c.ctx(ctx), opts)
+}
+
+// Add records module@version in gopkg.toml without installing it.
+func (c *Client) Add(module, version string) error {
+	return c.project.Add(module, version)
+}
+
+func (c *Client) Remove(module string) error {
+	return c.project.Remove(module)
+}
+
+// Update moves modules to new versions, following the [policy] table of
+// gopkg.toml for "latest", and installs them. Empty Targets updates every
+// dependency.
+func (c *Client) Update(ctx context.Context, opts UpdateOptions) ([]ModuleResult, error) {
+	return c.project.Update(c.ctx(ctx), opts)
+}
+
+func (c *Client) Check(ctx context.Context) ([]ModuleResult, error) {
+	return c.project.Check(c.ctx(ctx))
+}
+
+func (c *Client) Resolve(ctx context.Context, module, version string) (*Metadata, error) {
+	return c.project.Resolve(c.ctx(ctx), module, version)
+}